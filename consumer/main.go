@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/japablazatww/centralnexus/nexus/generated"
 )
@@ -9,50 +12,93 @@ import (
 func main() {
 	client := generated.NewClient("http://localhost:8080")
 
-	// 1. Check System Status (using generic Params)
+	// Every call is bounded; cancellation reaches libraries taking a context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 1. Check System Status (typed request)
 	fmt.Println("--- Testing GetSystemStatus ---")
-	statusReq := generated.GenericRequest{
-		Params: map[string]interface{}{
-			"code": "ADMIN123",
-		},
-	}
 	// NOTICE: Using namespaced LibreriaA
-	status, err := client.LibreriaA.GetSystemStatus(statusReq)
+	status, err := client.LibreriaA.GetSystemStatus(ctx, generated.LibreriaAGetSystemStatusRequest{
+		Code: "ADMIN123",
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	} else {
+		fmt.Printf("System Status: %s\n", status.Result)
+	}
+
+	// 2. Get User Balance (typed request, float64 result)
+	fmt.Println("\n--- Testing GetUserBalance ---")
+	balance, err := client.LibreriaA.GetUserBalance(ctx, generated.LibreriaAGetUserBalanceRequest{
+		UserID:    "user_001",
+		AccountID: "acc_999",
+	})
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 	} else {
-		fmt.Printf("System Status: %v\n", status)
+		fmt.Printf("Balance: %.2f\n", balance.Result)
 	}
 
-	// 2. Get User Balance (Testing different Cases)
-	fmt.Println("\n--- Testing GetUserBalance (CamelCase/SnakeCase check) ---")
+	// 3. Get User Balance (generic Params, CamelCase/SnakeCase check)
+	fmt.Println("\n--- Testing GetUserBalanceGeneric (CamelCase/SnakeCase check) ---")
 	balanceReq := generated.GenericRequest{
 		Params: map[string]interface{}{
 			"user_id":   "user_001", // Snake
 			"AccountId": "acc_999",  // Pascal
 		},
 	}
-	balance, err := client.LibreriaA.GetUserBalance(balanceReq)
+	rawBalance, err := client.LibreriaA.GetUserBalanceGeneric(ctx, balanceReq)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 	} else {
-		fmt.Printf("Balance: %v\n", balance)
+		fmt.Printf("Balance: %v\n", rawBalance)
 	}
 
-	// 3. Transfer
+	// 4. Transfer
 	fmt.Println("\n--- Testing Transfer ---")
-	transferReq := generated.GenericRequest{
-		Params: map[string]interface{}{
-			"sourceAccount": "acc_999",
-			"destAccount":   "acc_888",
-			"amount":        50.0,
-			"currency":      "GTQ",
-		},
-	}
-	txID, err := client.LibreriaA.Transfer(transferReq)
+	tx, err := client.LibreriaA.Transfer(ctx, generated.LibreriaATransferRequest{
+		SourceAccount: "acc_999",
+		DestAccount:   "acc_888",
+		Amount:        50.0,
+		Currency:      "GTQ",
+	})
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 	} else {
-		fmt.Printf("Transfer ID: %v\n", txID)
+		fmt.Printf("Transfer ID: %s\n", tx.Result)
+	}
+
+	// 5. Structured errors: a missing param is reported as an *APIError
+	fmt.Println("\n--- Testing Error Envelope ---")
+	_, err = client.LibreriaA.GetUserBalanceGeneric(ctx, generated.GenericRequest{
+		Params: map[string]interface{}{"user_id": "user_001"},
+	})
+	var apiErr *generated.APIError
+	if errors.As(err, &apiErr) {
+		fmt.Printf("Error %d %s (param %q, missing: %t)\n", apiErr.StatusCode, apiErr.Code, apiErr.Parameter, errors.Is(err, generated.ErrMissingParam))
+	} else {
+		fmt.Printf("Unexpected result: %v\n", err)
+	}
+
+	// 6. Batch: calls run in order and stop at the first failure
+	fmt.Println("\n--- Testing Batch ---")
+	results, err := client.Batch().
+		Add("libreria-a", "GetSystemStatus", generated.LibreriaAGetSystemStatusRequest{Code: "ADMIN123"}).
+		Add("libreria-a", "GetUserBalance", map[string]interface{}{"user_id": "user_001"}).
+		Add("libreria-a", "Transfer", generated.LibreriaATransferRequest{SourceAccount: "acc_999", DestAccount: "acc_888", Amount: 10, Currency: "GTQ"}).
+		Do(ctx)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+	for i, r := range results {
+		if err := r.Err(); err != nil {
+			fmt.Printf("%d. %s: %v\n", i+1, r.Method, err)
+			continue
+		}
+		var out map[string]interface{}
+		if err := r.Decode(&out); err == nil {
+			fmt.Printf("%d. %s: %v\n", i+1, r.Method, out["result"])
+		}
 	}
 }
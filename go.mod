@@ -1,5 +1,23 @@
 module github.com/japablazatww/centralnexus
 
-go 1.23
+go 1.23.0
 
-require github.com/japablazatww/libreria-a v0.0.0-20251210014148-98be375c22aa // indirect
+require (
+	github.com/japablazatww/libreria-a v0.0.0-20251210014148-98be375c22aa
+	google.golang.org/grpc v1.71.1
+	google.golang.org/protobuf v1.36.5
+)
+
+require (
+	golang.org/x/net v0.37.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250115164207-1a7da9e5054f // indirect
+)
+
+require (
+	golang.org/x/mod v0.24.0
+	golang.org/x/sync v0.12.0 // indirect
+	golang.org/x/tools v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// CatalogSchemaVersion is the catalog layout written by this CLI. Catalogs
+// without schema_version are version 1 and are migrated when loaded.
+//
+//	1: services (inputs/outputs, or the older "parameters"), types, errors
+//	2: header with build time, CLI, Go and module versions
+const CatalogSchemaVersion = 2
+
+// ModuleVersion is a library module indexed into a catalog.
+type ModuleVersion struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// newCatalog returns an empty catalog stamped with the current build.
+func newCatalog() Catalog {
+	return Catalog{
+		SchemaVersion: CatalogSchemaVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		CLIVersion:    cliVersion(),
+		GoVersion:     runtime.Version(),
+		Modules:       []ModuleVersion{},
+		Types:         []TypeEntry{},
+		Errors:        []ErrorEntry{},
+	}
+}
+
+// cliVersion returns the module version nexus-cli was installed at.
+func cliVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// catalogHash identifies the API a catalog describes: its modules,
+// services, types and errors, without the build header. Rebuilding an
+// unchanged API gives the same hash.
+func catalogHash(cat Catalog) string {
+	data, _ := json.Marshal(struct {
+		Modules  []ModuleVersion
+		Services []ServiceEntry
+		Types    []TypeEntry
+		Errors   []ErrorEntry
+	}{cat.Modules, cat.Services, cat.Types, cat.Errors})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// loadCatalog decodes a catalog of any schema version up to
+// CatalogSchemaVersion, migrating older layouts to the current one.
+func loadCatalog(data []byte) (Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return catalog, err
+	}
+	if catalog.SchemaVersion > CatalogSchemaVersion {
+		return catalog, fmt.Errorf("catalog schema version %d is newer than this nexus-cli supports (%d), update nexus-cli", catalog.SchemaVersion, CatalogSchemaVersion)
+	}
+	if catalog.SchemaVersion == 0 {
+		catalog.SchemaVersion = 1
+	}
+
+	if catalog.SchemaVersion == 1 {
+		// Early catalogs listed inputs as "parameters".
+		var legacy struct {
+			Services []struct {
+				Parameters []ParamMetadata `json:"parameters"`
+			} `json:"services"`
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return catalog, err
+		}
+		for i := range catalog.Services {
+			if catalog.Services[i].Inputs == nil && i < len(legacy.Services) {
+				catalog.Services[i].Inputs = legacy.Services[i].Parameters
+			}
+		}
+		catalog.MigratedFrom = 1
+		catalog.SchemaVersion = 2
+	}
+
+	for i := range catalog.Services {
+		if catalog.Services[i].Inputs == nil {
+			catalog.Services[i].Inputs = []ParamMetadata{}
+		}
+		if catalog.Services[i].Outputs == nil {
+			catalog.Services[i].Outputs = []ParamMetadata{}
+		}
+	}
+	if catalog.Modules == nil {
+		catalog.Modules = []ModuleVersion{}
+	}
+	if catalog.Types == nil {
+		catalog.Types = []TypeEntry{}
+	}
+	if catalog.Errors == nil {
+		catalog.Errors = []ErrorEntry{}
+	}
+	return catalog, nil
+}
+
+// readCatalogFile reads and migrates the catalog at path.
+func readCatalogFile(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Catalog{}, err
+	}
+	catalog, err := loadCatalog(data)
+	if err != nil {
+		return catalog, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// --- catalog subcommand ---
+
+func runCatalog(args []string) {
+	if len(args) == 0 || args[0] != "info" {
+		fmt.Println("Usage: nexus-cli catalog info [--file catalog.json] [--output format]")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("catalog info", flag.ExitOnError)
+	file := cmd.String("file", "", "Catalog to inspect (default ~/.nexus/catalog.json)")
+	out := addOutputFlags(cmd)
+	cmd.Parse(args[1:])
+	out.apply()
+
+	path := *file
+	if path == "" {
+		path = resolveDefaultCatalog()
+	}
+	catalog, err := readCatalogFile(path)
+	if err != nil {
+		fmt.Fprintf(out.Progress(), "Error reading catalog: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !out.Text() {
+		printCatalogInfo(*out, path, catalog)
+		return
+	}
+
+	fmt.Printf("Catalog: %s\n", path)
+	if catalog.MigratedFrom != 0 {
+		fmt.Printf("Schema version: %d (migrated from %d, rebuild to update the file)\n", catalog.SchemaVersion, catalog.MigratedFrom)
+	} else {
+		fmt.Printf("Schema version: %d\n", catalog.SchemaVersion)
+	}
+	fmt.Printf("Generated at: %s\n", orUnknown(catalog.GeneratedAt))
+	fmt.Printf("CLI version: %s\n", orUnknown(catalog.CLIVersion))
+	fmt.Printf("Go version: %s\n", orUnknown(catalog.GoVersion))
+	fmt.Println("Modules:")
+	for _, m := range catalog.Modules {
+		fmt.Printf("- %s %s\n", m.Path, m.Version)
+	}
+	fmt.Printf("Services: %d, Types: %d, Errors: %d\n", len(catalog.Services), len(catalog.Types), len(catalog.Errors))
+}
+
+// CatalogInfo is what catalog info reports with --output.
+type CatalogInfo struct {
+	Path          string          `json:"path"`
+	SchemaVersion int             `json:"schema_version"`
+	MigratedFrom  int             `json:"migrated_from,omitempty"`
+	GeneratedAt   string          `json:"generated_at,omitempty"`
+	CLIVersion    string          `json:"cli_version,omitempty"`
+	GoVersion     string          `json:"go_version,omitempty"`
+	Modules       []ModuleVersion `json:"modules"`
+	Services      int             `json:"services"`
+	Types         int             `json:"types"`
+	Errors        int             `json:"errors"`
+}
+
+func printCatalogInfo(out OutputOptions, path string, catalog Catalog) {
+	info := CatalogInfo{
+		Path:          path,
+		SchemaVersion: catalog.SchemaVersion,
+		MigratedFrom:  catalog.MigratedFrom,
+		GeneratedAt:   catalog.GeneratedAt,
+		CLIVersion:    catalog.CLIVersion,
+		GoVersion:     catalog.GoVersion,
+		Modules:       catalog.Modules,
+		Services:      len(catalog.Services),
+		Types:         len(catalog.Types),
+		Errors:        len(catalog.Errors),
+	}
+	output := Output{Value: info, Headers: []string{"Field", "Value"}}
+	output.Rows = [][]string{
+		{"Catalog", path},
+		{"Schema version", strconv.Itoa(catalog.SchemaVersion)},
+		{"Generated at", orUnknown(catalog.GeneratedAt)},
+		{"CLI version", orUnknown(catalog.CLIVersion)},
+		{"Go version", orUnknown(catalog.GoVersion)},
+	}
+	for _, m := range catalog.Modules {
+		output.Rows = append(output.Rows, []string{"Module", m.Path + " " + m.Version})
+	}
+	output.Rows = append(output.Rows,
+		[]string{"Services", strconv.Itoa(info.Services)},
+		[]string{"Types", strconv.Itoa(info.Types)},
+		[]string{"Errors", strconv.Itoa(info.Errors)})
+	for _, s := range catalog.Services {
+		output.IDs = append(output.IDs, s.Namespace+"."+s.FullMethod())
+	}
+	out.print(output)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
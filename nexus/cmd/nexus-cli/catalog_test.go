@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantMigrated int
+		wantInputs   []ParamMetadata // Of the first service
+		wantErr      string
+	}{
+		{
+			name:         "v1 parameters",
+			data:         `{"services": [{"namespace": "libreria-a", "method": "Transfer", "parameters": [{"name": "amount", "type": "float64"}]}]}`,
+			wantMigrated: 1,
+			wantInputs:   []ParamMetadata{{Name: "amount", Type: "float64"}},
+		},
+		{
+			name:         "v1 inputs",
+			data:         `{"services": [{"namespace": "libreria-a", "method": "Transfer", "inputs": [{"name": "amount", "type": "float64"}]}]}`,
+			wantMigrated: 1,
+			wantInputs:   []ParamMetadata{{Name: "amount", Type: "float64"}},
+		},
+		{
+			name:         "v1 inputs over parameters",
+			data:         `{"schema_version": 1, "services": [{"namespace": "libreria-a", "method": "Transfer", "inputs": [{"name": "amount", "type": "float64"}], "parameters": [{"name": "old", "type": "string"}]}]}`,
+			wantMigrated: 1,
+			wantInputs:   []ParamMetadata{{Name: "amount", Type: "float64"}},
+		},
+		{
+			name:         "v1 without params",
+			data:         `{"services": [{"namespace": "libreria-a", "method": "Ping"}]}`,
+			wantMigrated: 1,
+			wantInputs:   []ParamMetadata{},
+		},
+		{
+			name:       "v2",
+			data:       `{"schema_version": 2, "generated_at": "2026-01-02T03:04:05Z", "services": [{"namespace": "libreria-a", "method": "Transfer", "inputs": [{"name": "amount", "type": "float64"}]}]}`,
+			wantInputs: []ParamMetadata{{Name: "amount", Type: "float64"}},
+		},
+		{
+			name:    "newer",
+			data:    `{"schema_version": 3, "services": []}`,
+			wantErr: "newer than this nexus-cli supports",
+		},
+		{
+			name:    "invalid",
+			data:    `{"services": {}}`,
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat, err := loadCatalog([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cat.SchemaVersion != CatalogSchemaVersion {
+				t.Errorf("schema version %d, want %d", cat.SchemaVersion, CatalogSchemaVersion)
+			}
+			if cat.MigratedFrom != tt.wantMigrated {
+				t.Errorf("migrated from %d, want %d", cat.MigratedFrom, tt.wantMigrated)
+			}
+			if !reflect.DeepEqual(cat.Services[0].Inputs, tt.wantInputs) {
+				t.Errorf("inputs %+v, want %+v", cat.Services[0].Inputs, tt.wantInputs)
+			}
+			if cat.Services[0].Outputs == nil || cat.Modules == nil || cat.Types == nil || cat.Errors == nil {
+				t.Errorf("nil lists left in %+v", cat)
+			}
+		})
+	}
+}
+
+func TestCatalogHash(t *testing.T) {
+	cat := func() Catalog {
+		c := newCatalog()
+		c.Services = []ServiceEntry{{Namespace: "libreria-a", Method: "Transfer", Inputs: []ParamMetadata{{Name: "amount", Type: "float64"}}}}
+		return c
+	}
+	base := catalogHash(cat())
+
+	rebuilt := cat()
+	rebuilt.GeneratedAt, rebuilt.CLIVersion, rebuilt.GoVersion = "2030-01-01T00:00:00Z", "v9.9.9", "go9.9"
+	if got := catalogHash(rebuilt); got != base {
+		t.Errorf("hash changed with the build header: %s, was %s", got, base)
+	}
+
+	changed := cat()
+	changed.Services[0].Inputs[0].Type = "string"
+	if got := catalogHash(changed); got == base {
+		t.Errorf("hash %s unchanged after a param type change", got)
+	}
+	if len(base) != 12 {
+		t.Errorf("hash %q, want 12 hex digits", base)
+	}
+}
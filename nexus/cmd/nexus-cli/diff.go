@@ -0,0 +1,300 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// CatalogChange is a difference between two catalogs for one service or
+// library type.
+type CatalogChange struct {
+	Service  string `json:"service"` // namespace.Method, namespace.Type.Method or namespace.Type
+	Kind     string `json:"kind"`    // method_added, param_renamed, output_retyped, ...
+	Detail   string `json:"detail"`
+	Breaking bool   `json:"breaking"`
+}
+
+// diffCatalogs compares the services and library types of two catalogs.
+// Changes are breaking when a client of the old catalog can no longer call
+// the new one as before: clients send params by name and read outputs by
+// key. A removed param is breaking too: the server ignores it, so the value
+// a client sends silently stops reaching the library.
+func diffCatalogs(oldCat, newCat Catalog) []CatalogChange {
+	index := func(cat Catalog) map[string]ServiceEntry {
+		m := map[string]ServiceEntry{}
+		for _, s := range cat.Services {
+			m[s.Namespace+"."+s.FullMethod()] = s
+		}
+		return m
+	}
+	oldSvcs, newSvcs := index(oldCat), index(newCat)
+
+	var changes []CatalogChange
+	add := func(service, kind string, breaking bool, format string, args ...interface{}) {
+		changes = append(changes, CatalogChange{Service: service, Kind: kind, Detail: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+
+	for name, old := range oldSvcs {
+		svc, ok := newSvcs[name]
+		if !ok {
+			add(name, "method_removed", true, "method removed")
+			continue
+		}
+		if old.Version != svc.Version && old.Version != "" && svc.Version != "" {
+			add(name, "version_changed", false, "indexed from %s, was %s", svc.Version, old.Version)
+		}
+		switch {
+		case old.Path != "" && svc.Path == "":
+			add(name, "route_removed", true, "route %s removed, the method is only described", old.Path)
+		case old.Path != "" && svc.Path != old.Path:
+			add(name, "route_changed", true, "route changed from %s to %s", old.Path, svc.Path)
+		case old.Path == "" && svc.Path != "":
+			add(name, "route_added", false, "served at %s", svc.Path)
+		}
+
+		// Inputs, matched the way getParam does (case and underscores ignored).
+		oldIn, newIn := paramsByKey(old.Inputs), paramsByKey(svc.Inputs)
+		var removed, added []ParamMetadata
+		for _, p := range old.Inputs {
+			if q, ok := newIn[normalize(p.Name)]; !ok {
+				removed = append(removed, p)
+			} else if q.Type != p.Type {
+				add(name, "param_retyped", true, "param %s changed type from %s to %s", p.Name, p.Type, q.Type)
+			}
+		}
+		for _, p := range svc.Inputs {
+			if _, ok := oldIn[normalize(p.Name)]; !ok {
+				added = append(added, p)
+			}
+		}
+		// A param removed and one of the same type added at the same
+		// position is reported as a rename.
+		for i := 0; i < len(removed); i++ {
+			for j := 0; j < len(added); j++ {
+				if removed[i].Type == added[j].Type && paramIndex(old.Inputs, removed[i].Name) == paramIndex(svc.Inputs, added[j].Name) {
+					add(name, "param_renamed", true, "param %s renamed to %s", removed[i].Name, added[j].Name)
+					removed = append(removed[:i], removed[i+1:]...)
+					added = append(added[:j], added[j+1:]...)
+					i--
+					break
+				}
+			}
+		}
+		for _, p := range removed {
+			add(name, "param_removed", true, "param %s (%s) removed, clients still sending it are ignored", p.Name, p.Type)
+		}
+		for _, p := range added {
+			add(name, "param_added", true, "param %s (%s) added and required", p.Name, p.Type)
+		}
+
+		// Outputs, by the key of the JSON response.
+		oldOut, newOut := responseFields(old.Outputs), responseFields(svc.Outputs)
+		for _, key := range sortedKeys(oldOut) {
+			if t, ok := newOut[key]; !ok {
+				add(name, "output_removed", true, "output %s (%s) removed", key, oldOut[key])
+			} else if t != oldOut[key] {
+				add(name, "output_retyped", true, "output %s changed type from %s to %s", key, oldOut[key], t)
+			}
+		}
+		for _, key := range sortedKeys(newOut) {
+			if _, ok := oldOut[key]; !ok {
+				add(name, "output_added", false, "output %s (%s) added", key, newOut[key])
+			}
+		}
+		if !returnsError(old.Outputs) && returnsError(svc.Outputs) {
+			add(name, "error_added", false, "method can now fail with an error")
+		}
+	}
+	for name := range newSvcs {
+		if _, ok := oldSvcs[name]; !ok {
+			add(name, "method_added", false, "method added")
+		}
+	}
+
+	// Library types, field by field. Params reject unknown struct fields and
+	// clients read responses by key; a missing field is its zero value.
+	oldTypes, newTypes := typesByName(oldCat), typesByName(newCat)
+	for name, old := range oldTypes {
+		t, ok := newTypes[name]
+		if !ok {
+			add(name, "type_removed", true, "type removed")
+			continue
+		}
+		oldFields, newFields := fieldTypes(old), fieldTypes(t)
+		for _, key := range sortedKeys(oldFields) {
+			if typ, ok := newFields[key]; !ok {
+				add(name, "field_removed", true, "field %s (%s) removed", key, oldFields[key])
+			} else if typ != oldFields[key] {
+				add(name, "field_retyped", true, "field %s changed type from %s to %s", key, oldFields[key], typ)
+			}
+		}
+		for _, key := range sortedKeys(newFields) {
+			if _, ok := oldFields[key]; !ok {
+				add(name, "field_added", false, "field %s (%s) added", key, newFields[key])
+			}
+		}
+	}
+	for name := range newTypes {
+		if _, ok := oldTypes[name]; !ok {
+			add(name, "type_added", false, "type added")
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		return changes[i].Service < changes[j].Service
+	})
+	return changes
+}
+
+func typesByName(cat Catalog) map[string]TypeEntry {
+	m := map[string]TypeEntry{}
+	for _, t := range cat.Types {
+		m[t.Namespace+"."+t.Name] = t
+	}
+	return m
+}
+
+// fieldTypes returns the types of the fields of t by JSON key. Embedded
+// structs are listed under their type name, as in the catalog.
+func fieldTypes(t TypeEntry) map[string]string {
+	fields := map[string]string{}
+	for _, f := range t.Fields {
+		fields[f.Name] = f.Type
+	}
+	return fields
+}
+
+func paramsByKey(params []ParamMetadata) map[string]ParamMetadata {
+	m := map[string]ParamMetadata{}
+	for _, p := range params {
+		m[normalize(p.Name)] = p
+	}
+	return m
+}
+
+func paramIndex(params []ParamMetadata, name string) int {
+	for i, p := range params {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func returnsError(outputs []ParamMetadata) bool {
+	return len(outputs) > 0 && outputs[len(outputs)-1].Type == "error"
+}
+
+// responseFields returns the types of a response by JSON key, mirroring the
+// generated handlers: a single value is "result", several keep their names.
+func responseFields(outputs []ParamMetadata) map[string]string {
+	if returnsError(outputs) {
+		outputs = outputs[:len(outputs)-1]
+	}
+	fields := map[string]string{}
+	if len(outputs) == 1 {
+		fields["result"] = outputs[0].Type
+		return fields
+	}
+	for _, o := range outputs {
+		fields[toSnakeCase(o.Name)] = o.Type
+	}
+	return fields
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// --- diff subcommand ---
+
+// runDiff prints the changes between two catalogs and exits with 1 when any
+// of them is breaking (2 when the catalogs cannot be read).
+func runDiff(args []string) {
+	cmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	out := addOutputFlags(cmd)
+	cmd.Parse(args)
+	out.apply()
+	if cmd.NArg() != 2 {
+		fmt.Fprintln(out.Progress(), "Usage: nexus-cli diff [--output format] <old-catalog> <new-catalog>")
+		os.Exit(2)
+	}
+
+	var cats [2]Catalog
+	for i := range cats {
+		cat, err := readCatalogFile(cmd.Arg(i))
+		if err != nil {
+			fmt.Fprintf(out.Progress(), "Error reading catalog: %v\n", err)
+			os.Exit(2)
+		}
+		cats[i] = cat
+	}
+
+	changes := diffCatalogs(cats[0], cats[1])
+	breaking := 0
+	for _, c := range changes {
+		if c.Breaking {
+			breaking++
+		}
+	}
+	if !out.Text() {
+		printDiff(*out, changes, breaking)
+		if breaking > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	for _, c := range changes {
+		label := "compatible"
+		if c.Breaking {
+			label = "BREAKING"
+		}
+		fmt.Printf("%-10s %s: %s\n", label, c.Service, c.Detail)
+	}
+	fmt.Printf("\n%d breaking, %d compatible changes.\n", breaking, len(changes)-breaking)
+	if breaking > 0 {
+		os.Exit(1)
+	}
+}
+
+// CatalogDiff is what diff reports with --output.
+type CatalogDiff struct {
+	Breaking   int             `json:"breaking"`
+	Compatible int             `json:"compatible"`
+	Changes    []CatalogChange `json:"changes"`
+}
+
+func printDiff(out OutputOptions, changes []CatalogChange, breaking int) {
+	if changes == nil {
+		changes = []CatalogChange{}
+	}
+	output := Output{
+		Value:   CatalogDiff{Breaking: breaking, Compatible: len(changes) - breaking, Changes: changes},
+		Headers: []string{"Service", "Kind", "Breaking", "Detail"},
+	}
+	seen := map[string]bool{}
+	for _, c := range changes {
+		output.Rows = append(output.Rows, []string{c.Service, c.Kind, strconv.FormatBool(c.Breaking), c.Detail})
+		if !seen[c.Service] {
+			seen[c.Service] = true
+			output.IDs = append(output.IDs, c.Service)
+		}
+	}
+	out.print(output)
+}
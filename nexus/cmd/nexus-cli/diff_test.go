@@ -0,0 +1,152 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffCatalogs(t *testing.T) {
+	in := func(params ...string) []ParamMetadata {
+		var out []ParamMetadata
+		for i := 0; i < len(params); i += 2 {
+			out = append(out, ParamMetadata{Name: params[i], Type: params[i+1]})
+		}
+		return out
+	}
+	svc := func(method, path string, inputs, outputs []ParamMetadata) ServiceEntry {
+		return ServiceEntry{Namespace: "libreria-a", Method: method, Path: path, Inputs: inputs, Outputs: outputs}
+	}
+	typ := func(name string, fields ...string) TypeEntry {
+		t := TypeEntry{Namespace: "libreria-a", Name: name}
+		for i := 0; i < len(fields); i += 2 {
+			t.Fields = append(t.Fields, FieldMetadata{Name: fields[i], Type: fields[i+1]})
+		}
+		return t
+	}
+	transfer := svc("Transfer", "/liba/Transfer", in("source_account", "string", "amount", "float64"), in("ok", "bool", "err", "error"))
+
+	type change struct {
+		Kind     string
+		Breaking bool
+	}
+	tests := []struct {
+		name     string
+		old, new Catalog
+		want     []change
+	}{
+		{
+			name: "unchanged",
+			old:  Catalog{Services: []ServiceEntry{transfer}, Types: []TypeEntry{typ("Account", "id", "string")}},
+			new:  Catalog{Services: []ServiceEntry{transfer}, Types: []TypeEntry{typ("Account", "id", "string")}},
+		},
+		{
+			name: "method added and removed",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Ping", "/liba/Ping", nil, nil)}},
+			want: []change{{"method_removed", true}, {"method_added", false}},
+		},
+		{
+			name: "route removed",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "", transfer.Inputs, transfer.Outputs)}},
+			want: []change{{"route_removed", true}},
+		},
+		{
+			name: "route changed",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/bank/Transfer", transfer.Inputs, transfer.Outputs)}},
+			want: []change{{"route_changed", true}},
+		},
+		{
+			name: "route added",
+			old:  Catalog{Services: []ServiceEntry{svc("Transfer", "", transfer.Inputs, transfer.Outputs)}},
+			new:  Catalog{Services: []ServiceEntry{transfer}},
+			want: []change{{"route_added", false}},
+		},
+		{
+			name: "param renamed in place",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", in("from_account", "string", "amount", "float64"), transfer.Outputs)}},
+			want: []change{{"param_renamed", true}},
+		},
+		{
+			name: "param case change is not a rename",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", in("sourceAccount", "string", "amount", "float64"), transfer.Outputs)}},
+		},
+		{
+			name: "param removed",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", in("source_account", "string"), transfer.Outputs)}},
+			want: []change{{"param_removed", true}},
+		},
+		{
+			name: "param added and retyped",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", in("source_account", "string", "amount", "int64", "currency", "string"), transfer.Outputs)}},
+			want: []change{{"param_retyped", true}, {"param_added", true}},
+		},
+		{
+			name: "second output replaces result",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", transfer.Inputs, in("ok", "bool", "id", "string", "err", "error"))}},
+			want: []change{{"output_removed", true}, {"output_added", false}, {"output_added", false}},
+		},
+		{
+			name: "output retyped",
+			old:  Catalog{Services: []ServiceEntry{transfer}},
+			new:  Catalog{Services: []ServiceEntry{svc("Transfer", "/liba/Transfer", transfer.Inputs, in("ok", "string", "err", "error"))}},
+			want: []change{{"output_retyped", true}},
+		},
+		{
+			name: "error added",
+			old:  Catalog{Services: []ServiceEntry{svc("Ping", "/liba/Ping", nil, in("ok", "bool"))}},
+			new:  Catalog{Services: []ServiceEntry{svc("Ping", "/liba/Ping", nil, in("ok", "bool", "err", "error"))}},
+			want: []change{{"error_added", false}},
+		},
+		{
+			name: "version changed",
+			old:  Catalog{Services: []ServiceEntry{{Namespace: "libreria-a", Method: "Ping", Version: "v1.0.0"}}},
+			new:  Catalog{Services: []ServiceEntry{{Namespace: "libreria-a", Method: "Ping", Version: "v1.1.0"}}},
+			want: []change{{"version_changed", false}},
+		},
+		{
+			name: "type fields",
+			old:  Catalog{Types: []TypeEntry{typ("Account", "id", "string", "balance", "float64", "owner", "string")}},
+			new:  Catalog{Types: []TypeEntry{typ("Account", "id", "string", "balance", "int64", "currency", "string")}},
+			want: []change{{"field_retyped", true}, {"field_removed", true}, {"field_added", false}},
+		},
+		{
+			name: "type added and removed",
+			old:  Catalog{Types: []TypeEntry{typ("Account", "id", "string")}},
+			new:  Catalog{Types: []TypeEntry{typ("Wallet", "id", "string")}},
+			want: []change{{"type_removed", true}, {"type_added", false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []change
+			for _, c := range diffCatalogs(tt.old, tt.new) {
+				got = append(got, change{c.Kind, c.Breaking})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffCatalogsOrder(t *testing.T) {
+	old := Catalog{Services: []ServiceEntry{
+		{Namespace: "libreria-a", Method: "A"},
+		{Namespace: "libreria-a", Method: "B"},
+	}}
+	newCat := Catalog{Services: []ServiceEntry{
+		{Namespace: "libreria-a", Method: "C"},
+		{Namespace: "libreria-a", Method: "B"},
+	}}
+	changes := diffCatalogs(old, newCat)
+	if len(changes) != 2 || !changes[0].Breaking || changes[0].Service != "libreria-a.A" || changes[1].Service != "libreria-a.C" {
+		t.Errorf("breaking changes must come first: %+v", changes)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// defaultErrorsFile is read by build when present and no -errors flag is given.
+const defaultErrorsFile = "nexus-errors.json"
+
+// ErrorMetadata is an exported sentinel (var ErrX = errors.New(...)) or error
+// type of a library, and the HTTP status and code its handlers answer with.
+type ErrorMetadata struct {
+	Name    string   // ErrAccountNotFound, InsufficientFundsError
+	Kind    string   // "sentinel" or "type"
+	GoTypes []string // errors.As targets of error types: liba.T and/or *liba.T
+	Code    string   // Stable machine-readable code, e.g. account_not_found
+	Status  int
+}
+
+// ErrorEntry describes a library error and its HTTP mapping in the catalog.
+type ErrorEntry struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`              // sentinel or type
+	Message     string `json:"message,omitempty"` // Text of errors.New sentinels
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description,omitempty"`
+}
+
+// ErrorOverride replaces the inferred code and/or status of a library error.
+// Zero fields keep the inferred value.
+type ErrorOverride struct {
+	Code   string `json:"code,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+// ErrorOverrides is the content of the errors file: overrides by catalog
+// namespace, then by error name.
+//
+//	{"libreria-a": {"ErrAccountNotFound": {"status": 410, "code": "account_closed"}}}
+type ErrorOverrides map[string]map[string]ErrorOverride
+
+// loadErrorOverrides reads the errors file. An empty path reads
+// defaultErrorsFile if it exists.
+func loadErrorOverrides(path string) (ErrorOverrides, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultErrorsFile); err != nil {
+			return nil, nil
+		}
+		path = defaultErrorsFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides ErrorOverrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	// The generated handlers answer with these statuses as errors.
+	for namespace, byName := range overrides {
+		for name, o := range byName {
+			if o.Status != 0 && (o.Status < 400 || o.Status > 599) {
+				return nil, fmt.Errorf("error parsing %s: %s.%s: status %d is not an error status (400-599)", path, namespace, name, o.Status)
+			}
+		}
+	}
+	return overrides, nil
+}
+
+// indexErrors collects the exported sentinel errors and error types of a
+// library. Each gets a code and status inferred from its name and message
+// (not found → 404, already exists/conflict → 409, anything else → 422)
+// unless overrides says otherwise.
+func indexErrors(pkg *packages.Package, lib LibraryMetadata, overrides map[string]ErrorOverride, w io.Writer, debug bool) ([]ErrorMetadata, []ErrorEntry) {
+	errorIface := errorType.Underlying().(*types.Interface)
+	var errs []ErrorMetadata
+	var entries []ErrorEntry
+	add := func(meta ErrorMetadata, message, doc string) {
+		meta.Code, meta.Status = classifyError(meta.Name, message)
+		if o, ok := overrides[meta.Name]; ok {
+			if o.Code != "" {
+				meta.Code = o.Code
+			}
+			if o.Status != 0 {
+				meta.Status = o.Status
+			}
+		}
+		if debug {
+			fmt.Fprintf(w, "DEBUG: Found error %s (%s) -> %d %s\n", meta.Name, meta.Kind, meta.Status, meta.Code)
+		}
+		errs = append(errs, meta)
+		entries = append(entries, ErrorEntry{
+			Namespace:   lib.Namespace,
+			Name:        meta.Name,
+			Kind:        meta.Kind,
+			Message:     message,
+			Code:        meta.Code,
+			Status:      meta.Status,
+			Description: strings.TrimSpace(doc),
+		})
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || (gen.Tok != token.VAR && gen.Tok != token.TYPE) {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for i, name := range spec.Names {
+						obj, ok := pkg.TypesInfo.Defs[name].(*types.Var)
+						if !ok || !name.IsExported() || !types.Implements(obj.Type(), errorIface) {
+							continue
+						}
+						message := ""
+						if i < len(spec.Values) {
+							message = errorMessage(pkg.TypesInfo, spec.Values[i])
+						}
+						add(ErrorMetadata{Name: name.Name, Kind: "sentinel"}, message, specDoc(gen, spec.Doc))
+					}
+				case *ast.TypeSpec:
+					obj, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
+					if !ok || !spec.Name.IsExported() || spec.TypeParams != nil || obj.IsAlias() {
+						continue
+					}
+					if _, ok := obj.Type().Underlying().(*types.Interface); ok {
+						continue // Interfaces are not values a library returns
+					}
+					goType := lib.PackageName + "." + obj.Name()
+					var targets []string
+					if types.Implements(obj.Type(), errorIface) {
+						// Value receivers: the library may return T or *T.
+						targets = []string{goType, "*" + goType}
+					} else if types.Implements(types.NewPointer(obj.Type()), errorIface) {
+						targets = []string{"*" + goType}
+					} else {
+						continue
+					}
+					add(ErrorMetadata{Name: obj.Name(), Kind: "type", GoTypes: targets}, "", specDoc(gen, spec.Doc))
+				}
+			}
+		}
+	}
+
+	for name := range overrides {
+		found := false
+		for _, e := range errs {
+			found = found || e.Name == name
+		}
+		if !found {
+			fmt.Fprintf(w, "Warning: error override %s.%s matches no exported error\n", lib.Namespace, name)
+		}
+	}
+	return errs, entries
+}
+
+// errorMessage returns the constant text of errors.New("...") or
+// fmt.Errorf("...") initializers, or "" for anything else.
+func errorMessage(info *types.Info, expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return ""
+	}
+	if name := fn.Pkg().Path() + "." + fn.Name(); name != "errors.New" && name != "fmt.Errorf" {
+		return ""
+	}
+	if tv := info.Types[call.Args[0]]; tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value)
+	}
+	return ""
+}
+
+// specDoc returns the doc comment of a spec, falling back to the comment of
+// its declaration when it declares a single spec.
+func specDoc(gen *ast.GenDecl, doc *ast.CommentGroup) string {
+	if text := doc.Text(); text != "" || len(gen.Specs) != 1 {
+		return text
+	}
+	return gen.Doc.Text()
+}
+
+// classifyError infers the code and status of a library error from its name
+// and message: ErrAccountNotFound → account_not_found, 404. Unknown or
+// missing values (ErrUnknownCurrency, ErrMissingField) are invalid input,
+// 422 like any other error that is neither a lookup nor a conflict.
+func classifyError(name string, message string) (string, int) {
+	base := strings.TrimSuffix(name, "Error")
+	if len(base) > 3 && strings.HasPrefix(base, "Err") && unicode.IsUpper(rune(base[3])) {
+		base = base[3:]
+	}
+	if base == "" {
+		base = name
+	}
+	code := toSnakeCase(base)
+
+	text := strings.ToLower(code + " " + message)
+	switch {
+	case containsAny(text, "not_found", "not found", "not_exist", "not exist", "no_such", "no such"):
+		return code, http.StatusNotFound
+	case containsAny(text, "exist", "conflict", "duplicate", "already"):
+		return code, http.StatusConflict
+	}
+	return code, http.StatusUnprocessableEntity
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// checkSource type-checks src as a library package, as loadLibrary would.
+func checkSource(t *testing.T, src string) *packages.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "lib.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("github.com/japablazatww/libreria-test", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &packages.Package{PkgPath: pkg.Path(), Name: pkg.Name(), Fset: fset, Syntax: []*ast.File{file}, Types: pkg, TypesInfo: info}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name, message string
+		code          string
+		status        int
+	}{
+		{"ErrAccountNotFound", "account not found", "account_not_found", http.StatusNotFound},
+		{"ErrNoSuchUser", "", "no_such_user", http.StatusNotFound},
+		{"ErrGone", "account does not exist", "gone", http.StatusNotFound},
+		{"ErrAccountExists", "", "account_exists", http.StatusConflict},
+		{"ErrDuplicateTransfer", "", "duplicate_transfer", http.StatusConflict},
+		{"ErrBusy", "transfer already running", "busy", http.StatusConflict},
+		{"ErrUnknownCurrency", "unknown currency", "unknown_currency", http.StatusUnprocessableEntity},
+		{"ErrMissingField", "missing field", "missing_field", http.StatusUnprocessableEntity},
+		{"ErrInsufficientFunds", "insufficient funds", "insufficient_funds", http.StatusUnprocessableEntity},
+		{"InsufficientFundsError", "", "insufficient_funds", http.StatusUnprocessableEntity},
+		{"Error", "", "error", http.StatusUnprocessableEntity},
+		{"Errand", "", "errand", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		code, status := classifyError(tt.name, tt.message)
+		if code != tt.code || status != tt.status {
+			t.Errorf("classifyError(%q, %q) = %s, %d; want %s, %d", tt.name, tt.message, code, status, tt.code, tt.status)
+		}
+	}
+}
+
+func TestLoadErrorOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    ErrorOverrides
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: `{"libreria-a": {"ErrAccountNotFound": {"status": 410, "code": "account_closed"}, "ErrLimit": {"code": "limit"}}}`,
+			want: ErrorOverrides{"libreria-a": {
+				"ErrAccountNotFound": {Code: "account_closed", Status: 410},
+				"ErrLimit":           {Code: "limit"},
+			}},
+		},
+		{name: "server error", data: `{"libreria-a": {"ErrDown": {"status": 503}}}`, want: ErrorOverrides{"libreria-a": {"ErrDown": {Status: 503}}}},
+		{name: "success status", data: `{"libreria-a": {"ErrAccountNotFound": {"status": 200}}}`, wantErr: "status 200 is not an error status"},
+		{name: "redirect", data: `{"libreria-a": {"ErrAccountNotFound": {"status": 302}}}`, wantErr: "libreria-a.ErrAccountNotFound: status 302"},
+		{name: "out of range", data: `{"libreria-a": {"ErrAccountNotFound": {"status": 600}}}`, wantErr: "status 600"},
+		{name: "invalid", data: `{"libreria-a": []}`, wantErr: "error parsing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), defaultErrorsFile)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadErrorOverrides(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overrides %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexErrors(t *testing.T) {
+	pkg := checkSource(t, `package libtest
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAccountNotFound is returned for unknown accounts.
+var ErrAccountNotFound = errors.New("account not found")
+
+var (
+	ErrAccountExists = fmt.Errorf("account already exists")
+	errInternal      = errors.New("internal")
+	Limit            = 10
+)
+
+// InsufficientFundsError reports a balance below the amount.
+type InsufficientFundsError struct{ Missing float64 }
+
+func (e InsufficientFundsError) Error() string { return "insufficient funds" }
+
+type LimitError struct{}
+
+func (e *LimitError) Error() string { return "limit" }
+
+type Failure interface{ error }
+`)
+	lib := LibraryMetadata{Namespace: "libreria-test", PackageName: "libtest"}
+	overrides := map[string]ErrorOverride{
+		"LimitError":    {Status: 429, Code: "rate_limited"},
+		"ErrNotDefined": {Status: 404},
+	}
+	var warnings bytes.Buffer
+	errs, entries := indexErrors(pkg, lib, overrides, &warnings, false)
+
+	want := []ErrorMetadata{
+		{Name: "ErrAccountNotFound", Kind: "sentinel", Code: "account_not_found", Status: 404},
+		{Name: "ErrAccountExists", Kind: "sentinel", Code: "account_exists", Status: 409},
+		{Name: "InsufficientFundsError", Kind: "type", GoTypes: []string{"libtest.InsufficientFundsError", "*libtest.InsufficientFundsError"}, Code: "insufficient_funds", Status: 422},
+		{Name: "LimitError", Kind: "type", GoTypes: []string{"*libtest.LimitError"}, Code: "rate_limited", Status: 429},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors\n%+v\nwant\n%+v", errs, want)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("%d catalog entries, want %d", len(entries), len(want))
+	}
+	if e := entries[0]; e.Message != "account not found" || e.Description != "ErrAccountNotFound is returned for unknown accounts." || e.Namespace != "libreria-test" {
+		t.Errorf("entry %+v", e)
+	}
+	if e := entries[1]; e.Message != "account already exists" {
+		t.Errorf("message of fmt.Errorf sentinel %q", e.Message)
+	}
+	if !strings.Contains(warnings.String(), "libreria-test.ErrNotDefined matches no exported error") {
+		t.Errorf("no warning for an unused override: %q", warnings.String())
+	}
+}
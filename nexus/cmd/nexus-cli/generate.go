@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// LibraryMetadata groups the parsed functions of a single registry library
+// together with the identifiers the generated code needs to reference it.
+type LibraryMetadata struct {
+	ImportPath  string             // github.com/japablazatww/libreria-a
+	PackageName string             // liba (used as import alias and route prefix)
+	Namespace   string             // libreria-a (catalog namespace)
+	ClientName  string             // LibreriaA (SDK field and client type prefix)
+	Functions   []FunctionMetadata // Includes service methods (Receiver set)
+	Services    []ServiceMetadata
+	Structs     []StructMetadata
+	Errors      []ErrorMetadata // Sentinels and error types mapped to HTTP statuses
+}
+
+type generatorData struct {
+	Package       string
+	HasServices   bool
+	Libraries     []LibraryMetadata
+	ServerImports []Import // Libraries plus packages of their parameter types
+	TypeImports   []Import // Packages referenced by request/response and DTO fields
+}
+
+// callData is the context of the shared "call" template, which renders the
+// invocation of a library function with its extracted arguments.
+type callData struct {
+	Lib  LibraryMetadata
+	Func FunctionMetadata
+}
+
+// generatedFiles maps each template to the file it renders into.
+var generatedFiles = []struct {
+	Template string
+	Output   string
+}{
+	{"server.go.tmpl", "server_gen.go"},
+	{"sdk.go.tmpl", "sdk_gen.go"},
+	{"types.go.tmpl", "types_gen.go"},
+	{"coerce.go.tmpl", "coerce_gen.go"},
+	{"errors.go.tmpl", "errors_gen.go"},
+	{"openapi.go.tmpl", "openapi_gen.go"},
+	{"catalog.go.tmpl", "catalog_gen.go"},
+	{"search.go.tmpl", "search_gen.go"},
+	{"rpc.go.tmpl", "rpc_gen.go"},
+	{"batch.go.tmpl", "batch_gen.go"},
+	{"grpc.go.tmpl", "grpc_gen.go"},
+	{"middleware.go.tmpl", "middleware_gen.go"},
+	{"recover.go.tmpl", "recover_gen.go"},
+}
+
+// generateCode renders the generated files (see generatedFiles) for every
+// library into outDir, plus a copy of the catalog used to produce them, its
+// OpenAPI document and its nexus.proto with the compiled descriptor set,
+// which the server embeds.
+func generateCode(outDir string, pkgName string, libs []LibraryMetadata, cat Catalog, w io.Writer, debug bool) error {
+	tmpl, err := template.New("nexus").Funcs(template.FuncMap{
+		"importSpec": func(imp Import) string {
+			if path.Base(imp.Path) == imp.Alias {
+				return strconv.Quote(imp.Path)
+			}
+			return imp.Alias + " " + strconv.Quote(imp.Path)
+		},
+		"comment": func(text string) string {
+			lines := strings.Split(strings.TrimSpace(text), "\n")
+			return "// " + strings.Join(lines, "\n// ")
+		},
+		"callData": func(lib LibraryMetadata, fn FunctionMetadata) callData {
+			return callData{Lib: lib, Func: fn}
+		},
+	}).ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return fmt.Errorf("error parsing templates: %w", err)
+	}
+
+	if err := checkTypeNames(libs); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("error creating output dir: %w", err)
+	}
+
+	data := generatorData{Package: pkgName, Libraries: libs}
+	var libImports, serverImports []Import
+	for _, lib := range libs {
+		libImports = append(libImports, Import{Alias: lib.PackageName, Path: lib.ImportPath})
+		data.HasServices = data.HasServices || len(lib.Services) > 0
+		for _, fn := range lib.Functions {
+			for _, p := range append(fn.Params, fn.Results...) {
+				serverImports = mergeImports(serverImports, p.Imports)
+				data.TypeImports = mergeImports(data.TypeImports, p.SDKImports)
+			}
+		}
+		for _, st := range lib.Structs {
+			for _, f := range st.Fields {
+				data.TypeImports = mergeImports(data.TypeImports, f.SDKImports)
+			}
+		}
+	}
+	data.ServerImports = mergeImports(libImports, serverImports)
+	for i, imp := range data.ServerImports {
+		if imp.Path == "context" { // Always imported by server.go.tmpl
+			data.ServerImports = append(data.ServerImports[:i], data.ServerImports[i+1:]...)
+			break
+		}
+	}
+
+	for _, f := range generatedFiles {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, f.Template, data); err != nil {
+			return fmt.Errorf("error rendering %s: %w", f.Template, err)
+		}
+
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("%s is not valid Go: %w", f.Output, err)
+		}
+
+		outPath := filepath.Join(outDir, f.Output)
+		if err := os.WriteFile(outPath, src, 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", outPath, err)
+		}
+		if debug {
+			fmt.Fprintf(w, "DEBUG: Generated %s\n", outPath)
+		}
+	}
+
+	catData, err := json.MarshalIndent(cat, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding catalog: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "catalog.json"), catData, 0644); err != nil {
+		return fmt.Errorf("error writing catalog: %w", err)
+	}
+	specData, err := openAPIJSON(cat)
+	if err != nil {
+		return fmt.Errorf("error encoding OpenAPI document: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "openapi.json"), specData, 0644); err != nil {
+		return fmt.Errorf("error writing OpenAPI document: %w", err)
+	}
+	schema := protoSchema(cat)
+	descData, err := schema.DescriptorSet()
+	if err != nil {
+		return fmt.Errorf("error compiling %s: %w", ProtoFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, ProtoFile), []byte(schema.Source()), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", ProtoFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "nexus.binpb"), descData, 0644); err != nil {
+		return fmt.Errorf("error writing descriptor set: %w", err)
+	}
+	return nil
+}
+
+// runtimeNames are the exported identifiers the templates declare whatever
+// the libraries: generated types must not reuse them.
+var runtimeNames = []string{
+	"APIError", "Batch", "BatchCall", "BatchLimits", "BatchRequest", "BatchResponse", "BatchResult",
+	"CallHandler", "CallInfo", "CatalogJSON", "CircuitBreaker", "Client", "ConfigureBatch",
+	"ConfigureCircuitBreaker", "ConfigureServices", "GenericRequest", "Interceptor", "Middleware",
+	"NewClient", "OpenAPISpec", "ParamError", "ProtoDescriptorSet", "ProtoSource", "RegisterGRPC",
+	"RegisterHandlers", "Services", "Use", "UseHTTP",
+	"CodeMethodNotAllowed", "CodeInvalidRequest", "CodeMissingParam", "CodeInvalidParam",
+	"CodeServiceUnavailable", "CodeServiceNotFound", "CodeSkipped", "CodeInternalError",
+	"CodeCircuitOpen", "CodeCatalogError", "CodeLibraryError", "CodeHTTPError",
+	"ErrMethodNotAllowed", "ErrInvalidRequest", "ErrMissingParam", "ErrInvalidParam",
+	"ErrServiceUnavailable", "ErrServiceNotFound", "ErrSkipped", "ErrInternalError",
+	"ErrCircuitOpen", "ErrCatalogError", "ErrLibraryError", "ErrHTTPError", "ErrNotFound", "ErrConflict", "ErrUnprocessable",
+	"RPCParseError", "RPCInvalidRequest", "RPCMethodNotFound", "RPCInvalidParams",
+	"RPCInternalError", "RPCServerError",
+}
+
+// checkTypeNames fails when two generated identifiers would share a name:
+// the DTOs, clients and request and response structs of every library,
+// prefixed with the client name of their library, and the fixed
+// identifiers of the runtime live in one package.
+func checkTypeNames(libs []LibraryMetadata) error {
+	declared := map[string]string{}
+	for _, name := range runtimeNames {
+		declared[name] = "the Nexus runtime identifier " + name
+	}
+	declare := func(name, what string) error {
+		if prev, ok := declared[name]; ok {
+			return fmt.Errorf("generated type %s would be both %s and %s; rename one of them or disable a library", name, prev, what)
+		}
+		declared[name] = what
+		return nil
+	}
+	for _, lib := range libs {
+		if err := declare(lib.ClientName+"Client", "the SDK client of "+lib.Namespace); err != nil {
+			return err
+		}
+		for _, svc := range lib.Services {
+			if err := declare(svc.FieldName+"Client", "the SDK client of "+lib.Namespace+"."+svc.Name); err != nil {
+				return err
+			}
+		}
+		for _, st := range lib.Structs {
+			if err := declare(st.TypeName, "the DTO of "+lib.Namespace+"."+st.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, lib := range libs {
+		for _, fn := range lib.Functions {
+			name := lib.Namespace + "." + strings.TrimPrefix(fn.Receiver+"."+fn.Name, ".")
+			if err := declare(fn.RequestStruct, "the request struct of "+name); err != nil {
+				return err
+			}
+			if err := declare(fn.ResponseStruct, "the response struct of "+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkGeneratedCode type-checks the package generated in outDir with go vet,
+// from the module that contains it.
+func checkGeneratedCode(outDir string) error {
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = outDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("generated code in %s does not compile: %s\nOutput: %s", outDir, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// toExportedName turns a namespace like "libreria-a" or a name like
+// "result_0" into an exported Go identifier ("LibreriaA", "Result0").
+func toExportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(toPascalCase(p))
+	}
+	return b.String()
+}
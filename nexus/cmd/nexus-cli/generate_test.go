@@ -0,0 +1,226 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckTypeNames(t *testing.T) {
+	fn := func(client, receiver, name string) FunctionMetadata {
+		return FunctionMetadata{
+			Name:           name,
+			Receiver:       receiver,
+			RequestStruct:  client + receiver + name + "Request",
+			ResponseStruct: client + receiver + name + "Response",
+		}
+	}
+	dto := func(client, name string) StructMetadata {
+		return StructMetadata{Name: name, TypeName: client + name}
+	}
+	liba := func(structs []StructMetadata, fns ...FunctionMetadata) LibraryMetadata {
+		return LibraryMetadata{Namespace: "libreria-a", ClientName: "LibreriaA", Structs: structs, Functions: fns}
+	}
+	libb := func(structs []StructMetadata, fns ...FunctionMetadata) LibraryMetadata {
+		return LibraryMetadata{Namespace: "libreria-b", ClientName: "LibreriaB", Structs: structs, Functions: fns}
+	}
+
+	tests := []struct {
+		name    string
+		libs    []LibraryMetadata
+		wantErr string
+	}{
+		{
+			name: "same function in two libraries",
+			libs: []LibraryMetadata{
+				liba(nil, fn("LibreriaA", "", "Transfer")),
+				libb(nil, fn("LibreriaB", "", "Transfer")),
+			},
+		},
+		{
+			name: "function and method of the same name",
+			libs: []LibraryMetadata{liba(nil, fn("LibreriaA", "", "Transfer"), fn("LibreriaA", "BankService", "Transfer"))},
+		},
+		{
+			name: "same DTO in two libraries",
+			libs: []LibraryMetadata{
+				liba([]StructMetadata{dto("LibreriaA", "Account")}),
+				libb([]StructMetadata{dto("LibreriaB", "Account")}),
+			},
+		},
+		{
+			name: "DTOs named like runtime types",
+			libs: []LibraryMetadata{liba([]StructMetadata{dto("LibreriaA", "APIError"), dto("LibreriaA", "Batch"), dto("LibreriaA", "Interceptor")})},
+		},
+		{
+			name: "DTO named like a request struct",
+			libs: []LibraryMetadata{liba([]StructMetadata{dto("LibreriaA", "TransferRequest")}, fn("LibreriaA", "", "Transfer"))},
+			wantErr: "generated type LibreriaATransferRequest would be both the DTO of libreria-a.TransferRequest " +
+				"and the request struct of libreria-a.Transfer",
+		},
+		{
+			name:    "DTO named like the client",
+			libs:    []LibraryMetadata{liba([]StructMetadata{dto("LibreriaA", "Client")})},
+			wantErr: "LibreriaAClient would be both the SDK client of libreria-a and the DTO of libreria-a.Client",
+		},
+		{
+			name: "DTO named like a service client",
+			libs: []LibraryMetadata{{Namespace: "libreria-a", ClientName: "LibreriaA",
+				Services: []ServiceMetadata{{Name: "BankService", FieldName: "LibreriaABankService"}},
+				Structs:  []StructMetadata{dto("LibreriaA", "BankServiceClient")}}},
+			wantErr: "LibreriaABankServiceClient would be both the SDK client of libreria-a.BankService and the DTO of libreria-a.BankServiceClient",
+		},
+		{
+			name:    "DTO named like a runtime type once prefixed",
+			libs:    []LibraryMetadata{{Namespace: "batch", ClientName: "Batch", Structs: []StructMetadata{dto("Batch", "Request")}}},
+			wantErr: "BatchRequest would be both the Nexus runtime identifier BatchRequest and the DTO of batch.Request",
+		},
+		{
+			name: "client names that collide",
+			libs: []LibraryMetadata{
+				{Namespace: "libreria-a", ClientName: "LibreriaA", Functions: []FunctionMetadata{fn("LibreriaA", "", "Transfer")}},
+				{Namespace: "libreria_a", ClientName: "LibreriaA", Functions: []FunctionMetadata{fn("LibreriaA", "", "Transfer")}},
+			},
+			wantErr: "the SDK client of libreria-a and the SDK client of libreria_a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTypeNames(tt.libs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuntimeNames(t *testing.T) {
+	// Every exported identifier of the checked-in generated package that
+	// does not come from libreria-a must be reserved by checkTypeNames.
+	files, err := filepath.Glob("../../generated/*_gen.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no generated files: %v", err)
+	}
+	reserved := map[string]bool{}
+	for _, name := range runtimeNames {
+		reserved[name] = true
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, obj := range file.Scope.Objects {
+			if ast.IsExported(name) && obj.Kind != ast.Bad && !strings.HasPrefix(name, "LibreriaA") && !reserved[name] {
+				t.Errorf("%s: %s %s is not in runtimeNames", filepath.Base(path), obj.Kind, name)
+			}
+		}
+	}
+}
+
+func TestParseLibraryStructNames(t *testing.T) {
+	pkg := checkSource(t, `package liba
+
+type Account struct{ ID string }
+
+type BankService struct{}
+
+func NewBankService() *BankService { return &BankService{} }
+
+func (s *BankService) Transfer(amount float64) error { return nil }
+
+func Transfer(amount float64) error { return nil }
+`)
+	lib, _, _ := parseLibrary(pkg, RegistryEntry{Path: "github.com/japablazatww/libreria-a"}, io.Discard, false)
+	got := map[string]string{}
+	for _, fn := range lib.Functions {
+		got[fn.RequestStruct] = fn.ResponseStruct
+	}
+	want := map[string]string{
+		"LibreriaATransferRequest":            "LibreriaATransferResponse",
+		"LibreriaABankServiceTransferRequest": "LibreriaABankServiceTransferResponse",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("request structs %v, want %v", got, want)
+	}
+	for req, resp := range want {
+		if got[req] != resp {
+			t.Errorf("request struct %s has response %q, want %s", req, got[req], resp)
+		}
+	}
+	if err := checkTypeNames([]LibraryMetadata{lib}); err != nil {
+		t.Errorf("checkTypeNames: %v", err)
+	}
+}
+
+func TestToExportedName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"libreria-a", "LibreriaA"},
+		{"libreria_a", "LibreriaA"},
+		{"result_0", "Result0"},
+		{"bank", "Bank"},
+	}
+	for _, tt := range tests {
+		if got := toExportedName(tt.name); got != tt.want {
+			t.Errorf("toExportedName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// writeModule writes files, keyed by slash-separated path, into a temporary
+// module example.com/tmp and returns its directory.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/tmp\n\ngo 1.23\n"
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheckGeneratedCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"compiles", "package gen\n\nfunc F() int { return 1 }\n", ""},
+		{"type error", "package gen\n\nfunc F() int { return \"1\" }\n", "does not compile"},
+		{"vet error", "package gen\n\nimport \"fmt\"\n\nfunc F() string { return fmt.Sprintf(\"%d\", \"x\") }\n", "does not compile"},
+		{"syntax error", "package gen\n\nfunc F( {\n", "does not compile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"gen.go": tt.src})
+			err := checkGeneratedCode(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "gen.go") {
+				t.Fatalf("error = %v, want %q naming gen.go", err, tt.wantErr)
+			}
+		})
+	}
+}
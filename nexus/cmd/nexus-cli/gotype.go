@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// Import is a package the generated code has to import to name a type.
+type Import struct {
+	Alias string
+	Path  string
+}
+
+// TypeDescriptor is the structured form of a Go type recorded in the catalog
+// next to its string rendering, so tools do not have to re-parse "[]map[string]int".
+type TypeDescriptor struct {
+	Kind     string            `json:"kind"`              // basic, named, type_param, pointer, slice, array, variadic, map, chan, func, struct, interface
+	Name     string            `json:"name,omitempty"`    // Type name for basic, named and type_param kinds
+	Package  string            `json:"package,omitempty"` // Import path of named types
+	Len      string            `json:"len,omitempty"`     // Array length
+	Dir      string            `json:"dir,omitempty"`     // Channel direction: both, send, recv
+	Elem     *TypeDescriptor   `json:"elem,omitempty"`
+	Key      *TypeDescriptor   `json:"key,omitempty"`
+	TypeArgs []*TypeDescriptor `json:"type_args,omitempty"`
+	Fields   []FieldDescriptor `json:"fields,omitempty"`  // Inline struct fields
+	Params   []*TypeDescriptor `json:"params,omitempty"`  // Func parameters
+	Results  []*TypeDescriptor `json:"results,omitempty"` // Func results
+}
+
+type FieldDescriptor struct {
+	Name string          `json:"name"`
+	Type *TypeDescriptor `json:"type"`
+	Tag  string          `json:"tag,omitempty"`
+}
+
+// typePrinter renders resolved types back to Go source. Aliases are always
+// expanded; named decides how a named type is spelled, including its type
+// arguments (see typeArgs).
+type typePrinter struct {
+	named func(t *types.Named) string
+}
+
+// typeArgs renders the "[A, B]" suffix of an instantiated generic type.
+func (p typePrinter) typeArgs(t *types.Named) string {
+	args := t.TypeArgs()
+	if args == nil || args.Len() == 0 {
+		return ""
+	}
+	parts := make([]string, args.Len())
+	for i := range parts {
+		parts[i] = p.render(args.At(i))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (p typePrinter) render(t types.Type) string {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		return t.Name()
+	case *types.Named:
+		return p.named(t)
+	case *types.TypeParam:
+		return t.Obj().Name()
+	case *types.Pointer:
+		return "*" + p.render(t.Elem())
+	case *types.Slice:
+		return "[]" + p.render(t.Elem())
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), p.render(t.Elem()))
+	case *types.Map:
+		return "map[" + p.render(t.Key()) + "]" + p.render(t.Elem())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendOnly:
+			return "chan<- " + p.render(t.Elem())
+		case types.RecvOnly:
+			return "<-chan " + p.render(t.Elem())
+		}
+		return "chan " + p.render(t.Elem())
+	case *types.Signature:
+		return "func" + p.signature(t)
+	case *types.Struct:
+		if t.NumFields() == 0 {
+			return "struct{}"
+		}
+		parts := make([]string, t.NumFields())
+		for i := range parts {
+			f := t.Field(i)
+			if f.Embedded() {
+				parts[i] = p.render(f.Type())
+			} else {
+				parts[i] = f.Name() + " " + p.render(f.Type())
+			}
+			if tag := t.Tag(i); tag != "" {
+				parts[i] += " `" + tag + "`"
+			}
+		}
+		return "struct{ " + strings.Join(parts, "; ") + " }"
+	case *types.Interface:
+		if t.Empty() {
+			return "interface{}"
+		}
+		var parts []string
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			parts = append(parts, p.render(t.EmbeddedType(i)))
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			parts = append(parts, m.Name()+p.signature(m.Type().(*types.Signature)))
+		}
+		return "interface{ " + strings.Join(parts, "; ") + " }"
+	case *types.Union:
+		parts := make([]string, t.Len())
+		for i := range parts {
+			term := t.Term(i)
+			parts[i] = p.render(term.Type())
+			if term.Tilde() {
+				parts[i] = "~" + parts[i]
+			}
+		}
+		return strings.Join(parts, " | ")
+	}
+	return "interface{}"
+}
+
+// signature renders "(params) results" without the func keyword.
+func (p typePrinter) signature(sig *types.Signature) string {
+	params := make([]string, sig.Params().Len())
+	for i := range params {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == len(params)-1 {
+			params[i] = "..." + p.render(t.(*types.Slice).Elem())
+		} else {
+			params[i] = p.render(t)
+		}
+	}
+	s := "(" + strings.Join(params, ", ") + ")"
+
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = p.render(sig.Results().At(i).Type())
+	}
+	switch len(results) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + results[0]
+	}
+	return s + " (" + strings.Join(results, ", ") + ")"
+}
+
+// goTypeString renders t as it must be written from the generated package and
+// reports every package it references. ok is false when the type cannot be
+// named there (unexported types, type parameters).
+func goTypeString(t types.Type, lib LibraryMetadata) (string, []Import, bool) {
+	var used []Import
+	ok := true
+	var p typePrinter
+	p = typePrinter{named: func(t *types.Named) string {
+		obj := t.Obj()
+		pkg := obj.Pkg()
+		if pkg == nil {
+			return obj.Name() // error, comparable
+		}
+		if !obj.Exported() {
+			ok = false
+		}
+		alias := pkg.Name()
+		if pkg.Path() == lib.ImportPath {
+			alias = lib.PackageName
+		}
+		used = append(used, Import{Alias: alias, Path: pkg.Path()})
+		return alias + "." + obj.Name() + p.typeArgs(t)
+	}}
+	s := p.render(t)
+	if containsTypeParam(t) {
+		ok = false
+	}
+	return s, used, ok
+}
+
+// sdkTypeString renders t for the SDK side of the generated package, which
+// must not import the libraries: library structs with a DTO (dtos) use the
+// DTO name, prefixed with the client name of the library, and any other
+// library type is replaced by its underlying type.
+func sdkTypeString(t types.Type, lib LibraryMetadata, dtos map[string]bool) (string, []Import) {
+	var used []Import
+	expanding := map[*types.Named]bool{} // Guards self-referencing unexported types
+	var p typePrinter
+	p = typePrinter{named: func(t *types.Named) string {
+		obj := t.Obj()
+		pkg := obj.Pkg()
+		switch {
+		case pkg == nil:
+			return obj.Name() // error, comparable
+		case pkg.Path() == lib.ImportPath:
+			if dtos[obj.Name()] && t.TypeArgs().Len() == 0 {
+				return lib.ClientName + obj.Name()
+			}
+			if _, ok := t.Underlying().(*types.Interface); ok || expanding[t] {
+				return "interface{}"
+			}
+			expanding[t] = true
+			defer delete(expanding, t)
+			return p.render(t.Underlying())
+		}
+		used = append(used, Import{Alias: pkg.Name(), Path: pkg.Path()})
+		return pkg.Name() + "." + obj.Name() + p.typeArgs(t)
+	}}
+	return p.render(t), used
+}
+
+// containsTypeParam reports whether t mentions a type parameter.
+func containsTypeParam(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		if args := t.TypeArgs(); args != nil {
+			for i := 0; i < args.Len(); i++ {
+				if containsTypeParam(args.At(i)) {
+					return true
+				}
+			}
+		}
+	case *types.Pointer:
+		return containsTypeParam(t.Elem())
+	case *types.Slice:
+		return containsTypeParam(t.Elem())
+	case *types.Array:
+		return containsTypeParam(t.Elem())
+	case *types.Chan:
+		return containsTypeParam(t.Elem())
+	case *types.Map:
+		return containsTypeParam(t.Key()) || containsTypeParam(t.Elem())
+	}
+	return false
+}
+
+// describeType builds the TypeDescriptor of a resolved type.
+func describeType(t types.Type) *TypeDescriptor {
+	describeTuple := func(tuple *types.Tuple) []*TypeDescriptor {
+		out := make([]*TypeDescriptor, tuple.Len())
+		for i := range out {
+			out[i] = describeType(tuple.At(i).Type())
+		}
+		return out
+	}
+
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		return &TypeDescriptor{Kind: "basic", Name: t.Name()}
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return &TypeDescriptor{Kind: "basic", Name: obj.Name()} // error, comparable
+		}
+		d := &TypeDescriptor{Kind: "named", Name: obj.Name(), Package: obj.Pkg().Path()}
+		if args := t.TypeArgs(); args != nil {
+			for i := 0; i < args.Len(); i++ {
+				d.TypeArgs = append(d.TypeArgs, describeType(args.At(i)))
+			}
+		}
+		return d
+	case *types.TypeParam:
+		return &TypeDescriptor{Kind: "type_param", Name: t.Obj().Name()}
+	case *types.Pointer:
+		return &TypeDescriptor{Kind: "pointer", Elem: describeType(t.Elem())}
+	case *types.Slice:
+		return &TypeDescriptor{Kind: "slice", Elem: describeType(t.Elem())}
+	case *types.Array:
+		return &TypeDescriptor{Kind: "array", Len: fmt.Sprint(t.Len()), Elem: describeType(t.Elem())}
+	case *types.Map:
+		return &TypeDescriptor{Kind: "map", Key: describeType(t.Key()), Elem: describeType(t.Elem())}
+	case *types.Chan:
+		dir := "both"
+		switch t.Dir() {
+		case types.SendOnly:
+			dir = "send"
+		case types.RecvOnly:
+			dir = "recv"
+		}
+		return &TypeDescriptor{Kind: "chan", Dir: dir, Elem: describeType(t.Elem())}
+	case *types.Signature:
+		return &TypeDescriptor{Kind: "func", Params: describeTuple(t.Params()), Results: describeTuple(t.Results())}
+	case *types.Struct:
+		d := &TypeDescriptor{Kind: "struct"}
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			d.Fields = append(d.Fields, FieldDescriptor{Name: f.Name(), Type: describeType(f.Type()), Tag: t.Tag(i)})
+		}
+		return d
+	}
+	return &TypeDescriptor{Kind: "interface"}
+}
+
+// mergeImports returns the distinct imports of all lists, keyed by path.
+func mergeImports(lists ...[]Import) []Import {
+	seen := map[string]bool{}
+	var out []Import
+	for _, list := range lists {
+		for _, imp := range list {
+			if seen[imp.Path] {
+				continue
+			}
+			seen[imp.Path] = true
+			out = append(out, imp)
+		}
+	}
+	return out
+}
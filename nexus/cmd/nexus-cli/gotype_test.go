@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+const gotypeSource = `package libtest
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Account struct{ ID string }
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+type status int
+
+type node struct{ next *node }
+
+type Handler interface{ Handle() error }
+
+type Alias = map[string]Account
+
+var (
+	Basic     int64
+	Bytes     []byte
+	Accounts  map[string][]*Account
+	Array     [4]float64
+	Time      time.Time
+	Durations []time.Duration
+	Generic   Pair[string, Account]
+	Private   status
+	Recursive *node
+	Iface     Handler
+	Aliased   Alias
+	Inline    struct{ Name string ` + "`json:\"name\"`" + `; io.Reader }
+	Func      func(context.Context, ...string) (int, error)
+	Send      chan<- Account
+	Err       error
+	Any       interface{}
+)
+`
+
+func TestTypeStrings(t *testing.T) {
+	pkg := checkSource(t, gotypeSource)
+	lib := LibraryMetadata{ImportPath: pkg.PkgPath, PackageName: "lt"}
+	dtos := map[string]bool{"Account": true, "Pair": true}
+
+	tests := []struct {
+		name    string
+		catalog string // typeToString, as written in the catalog
+		goType  string // goTypeString, from the generated server
+		ok      bool   // Nameable from the generated server
+		sdkType string // sdkTypeString, from the SDK
+	}{
+		{"Basic", "int64", "int64", true, "int64"},
+		{"Bytes", "[]byte", "[]byte", true, "[]byte"},
+		{"Accounts", "map[string][]*Account", "map[string][]*lt.Account", true, "map[string][]*Account"},
+		{"Array", "[4]float64", "[4]float64", true, "[4]float64"},
+		{"Time", "time.Time", "time.Time", true, "time.Time"},
+		{"Durations", "[]time.Duration", "[]time.Duration", true, "[]time.Duration"},
+		{"Generic", "Pair[string, Account]", "lt.Pair[string, lt.Account]", true, "struct{ Key string; Val Account }"},
+		{"Private", "status", "lt.status", false, "int"},
+		{"Recursive", "*node", "*lt.node", false, "*struct{ next *interface{} }"},
+		{"Iface", "Handler", "lt.Handler", true, "interface{}"},
+		{"Aliased", "map[string]Account", "map[string]lt.Account", true, "map[string]Account"},
+		{"Inline", "struct{ Name string `json:\"name\"`; io.Reader }", "struct{ Name string `json:\"name\"`; io.Reader }", true, "struct{ Name string `json:\"name\"`; io.Reader }"},
+		{"Func", "func(context.Context, ...string) (int, error)", "func(context.Context, ...string) (int, error)", true, "func(context.Context, ...string) (int, error)"},
+		{"Send", "chan<- Account", "chan<- lt.Account", true, "chan<- Account"},
+		{"Err", "error", "error", true, "error"},
+		{"Any", "interface{}", "interface{}", true, "interface{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := pkg.Types.Scope().Lookup(tt.name).Type()
+			if got := typeToString(typ, pkg.Types); got != tt.catalog {
+				t.Errorf("typeToString = %q, want %q", got, tt.catalog)
+			}
+			got, _, ok := goTypeString(typ, lib)
+			if got != tt.goType || ok != tt.ok {
+				t.Errorf("goTypeString = %q, %v; want %q, %v", got, ok, tt.goType, tt.ok)
+			}
+			if got, _ := sdkTypeString(typ, lib, dtos); got != tt.sdkType {
+				t.Errorf("sdkTypeString = %q, want %q", got, tt.sdkType)
+			}
+		})
+	}
+}
+
+func TestTypeStringImports(t *testing.T) {
+	pkg := checkSource(t, gotypeSource)
+	lib := LibraryMetadata{ImportPath: pkg.PkgPath, PackageName: "lt"}
+	typ := pkg.Types.Scope().Lookup("Func").Type()
+
+	_, imports, _ := goTypeString(types.NewSlice(pkg.Types.Scope().Lookup("Accounts").Type()), lib)
+	if want := []Import{{Alias: "lt", Path: pkg.PkgPath}}; !reflect.DeepEqual(imports, want) {
+		t.Errorf("server imports %v, want %v", imports, want)
+	}
+	_, imports = sdkTypeString(typ, lib, nil)
+	if want := []Import{{Alias: "context", Path: "context"}}; !reflect.DeepEqual(imports, want) {
+		t.Errorf("SDK imports %v, want %v", imports, want)
+	}
+	merged := mergeImports([]Import{{"lt", pkg.PkgPath}, {"time", "time"}}, []Import{{"time", "time"}, {"io", "io"}})
+	if len(merged) != 3 {
+		t.Errorf("mergeImports = %v, want 3 distinct paths", merged)
+	}
+}
+
+func TestDescribeType(t *testing.T) {
+	pkg := checkSource(t, gotypeSource)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Basic", `{"kind":"basic","name":"int64"}`},
+		{"Accounts", `{"kind":"map","elem":{"kind":"slice","elem":{"kind":"pointer","elem":{"kind":"named","name":"Account","package":"github.com/japablazatww/libreria-test"}}},"key":{"kind":"basic","name":"string"}}`},
+		{"Array", `{"kind":"array","len":"4","elem":{"kind":"basic","name":"float64"}}`},
+		{"Time", `{"kind":"named","name":"Time","package":"time"}`},
+		{"Generic", `{"kind":"named","name":"Pair","package":"github.com/japablazatww/libreria-test","type_args":[{"kind":"basic","name":"string"},{"kind":"named","name":"Account","package":"github.com/japablazatww/libreria-test"}]}`},
+		{"Inline", `{"kind":"struct","fields":[{"name":"Name","type":{"kind":"basic","name":"string"},"tag":"json:\"name\""},{"name":"Reader","type":{"kind":"named","name":"Reader","package":"io"}}]}`},
+		{"Send", `{"kind":"chan","dir":"send","elem":{"kind":"named","name":"Account","package":"github.com/japablazatww/libreria-test"}}`},
+		{"Err", `{"kind":"basic","name":"error"}`},
+		{"Any", `{"kind":"interface"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(describeType(pkg.Types.Scope().Lookup(tt.name).Type()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("describeType = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
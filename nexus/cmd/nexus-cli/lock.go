@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultLockFile records what build indexed, in the working directory.
+const defaultLockFile = "nexus.lock"
+
+// LockEntry is the resolved module of a registry library.
+type LockEntry struct {
+	Path    string `json:"path"`              // Registry import path
+	Module  string `json:"module"`            // Module providing it
+	Version string `json:"version"`           // Resolved module version, (devel) for local checkouts
+	Replace string `json:"replace,omitempty"` // Registry replacement, as written in the registry
+	Sum     string `json:"sum,omitempty"`     // go.sum hash of the module zip (h1:...), none for local checkouts
+}
+
+// Lockfile is the content of nexus.lock.
+type Lockfile struct {
+	Libraries []LockEntry `json:"libraries"`
+}
+
+// Find returns the entry of a registry import path.
+func (l Lockfile) Find(path string) (LockEntry, bool) {
+	for _, e := range l.Libraries {
+		if e.Path == path {
+			return e, true
+		}
+	}
+	return LockEntry{}, false
+}
+
+func readLockfile(path string) (Lockfile, error) {
+	var lock Lockfile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+func writeLockfile(path string, lock Lockfile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readBuildLockfile reads the lockfile a build resolves versions from: the
+// one of a locked build, which must exist, or of an offline build, where
+// queries such as latest cannot be resolved and a missing or unreadable
+// lockfile is ignored. Other builds resolve the registry versions again.
+func readBuildLockfile(path string, locked, offline bool) (Lockfile, error) {
+	switch {
+	case locked:
+		return readLockfile(path)
+	case offline && path != "":
+		lock, _ := readLockfile(path)
+		return lock, nil
+	}
+	return Lockfile{}, nil
+}
+
+// checkLockedRegistry fails when a library of lock is missing or disabled in
+// the registry: a locked build cannot leave it out.
+func checkLockedRegistry(lock Lockfile, libraries []RegistryEntry, lockFile string) error {
+	for _, l := range lock.Libraries {
+		found := false
+		for _, entry := range libraries {
+			found = found || (entry.Path == l.Path && !entry.Disabled)
+		}
+		if !found {
+			return fmt.Errorf("%s is locked but not enabled in the registry, run build without --locked to update %s", l.Path, lockFile)
+		}
+	}
+	return nil
+}
+
+// installVersion returns the version query installed for entry: the locked
+// version when lock has one, else the registry query. Replaced libraries,
+// in the registry or when locked, are never pinned by version.
+func installVersion(entry RegistryEntry, lock Lockfile) string {
+	if locked, ok := lock.Find(entry.Path); ok && locked.Replace == "" && entry.Replace == "" {
+		return locked.Version
+	}
+	return entry.VersionQuery()
+}
+
+// checkLockDrift fails when a locked build resolved mod differently from
+// the lockfile entry.
+func checkLockDrift(mod, locked LockEntry, lockFile string) error {
+	if mod != locked {
+		return fmt.Errorf("resolved %s@%s (%s), %s has %s@%s (%s)",
+			mod.Module, mod.Version, mod.Sum, lockFile, locked.Module, locked.Version, locked.Sum)
+	}
+	return nil
+}
+
+// resolveModule reports the module version the temp module selected for
+// pkg and its hash from the temp module's go.sum. Replaced modules report
+// the replacement's version and hash; local checkouts have neither.
+func resolveModule(withDir string, pkg string) (LockEntry, error) {
+	cmd := exec.Command("go", "list", "-f", "{{with .Module}}{{.Path}} {{.Version}}{{with .Replace}} {{.Path}} {{.Version}}{{end}}{{end}}", pkg)
+	cmd.Dir = withDir
+	output, err := cmd.Output()
+	if err != nil {
+		return LockEntry{}, fmt.Errorf("error running go list: %w", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return LockEntry{}, fmt.Errorf("no module version found for %s", pkg)
+	}
+
+	entry := LockEntry{Path: pkg, Module: fields[0], Version: fields[1]}
+	module, version := fields[0], fields[1]
+	switch len(fields) {
+	case 3: // Local directory
+		entry.Version = "(devel)"
+		return entry, nil
+	case 4:
+		module, version = fields[2], fields[3]
+		entry.Version = version
+	}
+	entry.Sum, err = goSumHash(filepath.Join(withDir, "go.sum"), module, version)
+	return entry, err
+}
+
+// goSumHash returns the hash of the module zip of module@version in a
+// go.sum file, not the hash of its go.mod.
+func goSumHash(path, module, version string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == module && fields[1] == version {
+			sum = fields[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if sum == "" {
+		return "", fmt.Errorf("no go.sum hash found for %s@%s", module, version)
+	}
+	return sum, nil
+}
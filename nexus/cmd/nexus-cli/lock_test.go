@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testLock = Lockfile{Libraries: []LockEntry{
+	{Path: "github.com/japablazatww/libreria-a", Module: "github.com/japablazatww/libreria-a", Version: "v1.2.3", Sum: "h1:abc="},
+	{Path: "github.com/japablazatww/libreria-b", Module: "github.com/japablazatww/libreria-b", Version: "(devel)", Replace: "../libreria-b"},
+}}
+
+func TestLockfileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultLockFile)
+	if err := writeLockfile(path, testLock); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLockfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, testLock) {
+		t.Errorf("read %+v, wrote %+v", got, testLock)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLockfile(path); err == nil || !strings.Contains(err.Error(), "error parsing") {
+		t.Errorf("reading invalid lockfile: got %v, want a parse error", err)
+	}
+}
+
+func TestLockfileFind(t *testing.T) {
+	tests := []struct {
+		path    string
+		version string
+		ok      bool
+	}{
+		{"github.com/japablazatww/libreria-a", "v1.2.3", true},
+		{"github.com/japablazatww/libreria-b", "(devel)", true},
+		{"github.com/japablazatww/libreria-c", "", false},
+	}
+	for _, tt := range tests {
+		e, ok := testLock.Find(tt.path)
+		if ok != tt.ok || e.Version != tt.version {
+			t.Errorf("Find(%s) = %q, %v; want %q, %v", tt.path, e.Version, ok, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestReadBuildLockfile(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, defaultLockFile)
+	if err := writeLockfile(present, testLock); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.lock")
+
+	tests := []struct {
+		name    string
+		path    string
+		locked  bool
+		offline bool
+		want    int // Libraries read
+		wantErr bool
+	}{
+		{"online", present, false, false, 0, false},
+		{"offline", present, false, true, 2, false},
+		{"offline without lockfile", missing, false, true, 0, false},
+		{"offline without path", "", false, true, 0, false},
+		{"locked", present, true, false, 2, false},
+		{"locked offline", present, true, true, 2, false},
+		{"locked without lockfile", missing, true, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock, err := readBuildLockfile(tt.path, tt.locked, tt.offline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(lock.Libraries) != tt.want {
+				t.Errorf("read %d libraries, want %d", len(lock.Libraries), tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry RegistryEntry
+		want  string
+	}{
+		{"locked", RegistryEntry{Path: "github.com/japablazatww/libreria-a", Version: "v1.2"}, "v1.2.3"},
+		{"registry replace", RegistryEntry{Path: "github.com/japablazatww/libreria-a", Replace: "../libreria-a"}, "latest"},
+		{"locked replace", RegistryEntry{Path: "github.com/japablazatww/libreria-b", Version: "v0.9.0"}, "v0.9.0"},
+		{"not locked", RegistryEntry{Path: "github.com/japablazatww/libreria-c", Version: "<v2.0.0"}, "<v2.0.0"},
+		{"not locked latest", RegistryEntry{Path: "github.com/japablazatww/libreria-c"}, "latest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := installVersion(tt.entry, testLock); got != tt.want {
+				t.Errorf("installVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLockedRegistry(t *testing.T) {
+	tests := []struct {
+		name      string
+		libraries []RegistryEntry
+		wantErr   string
+	}{
+		{"all enabled", []RegistryEntry{
+			{Path: "github.com/japablazatww/libreria-a"},
+			{Path: "github.com/japablazatww/libreria-b"},
+			{Path: "github.com/japablazatww/libreria-c"},
+		}, ""},
+		{"disabled", []RegistryEntry{
+			{Path: "github.com/japablazatww/libreria-a"},
+			{Path: "github.com/japablazatww/libreria-b", Disabled: true},
+		}, "libreria-b is locked but not enabled"},
+		{"removed", []RegistryEntry{
+			{Path: "github.com/japablazatww/libreria-b"},
+		}, "libreria-a is locked but not enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLockedRegistry(testLock, tt.libraries, defaultLockFile)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLockDrift(t *testing.T) {
+	locked := testLock.Libraries[0]
+	tests := []struct {
+		name    string
+		mutate  func(e *LockEntry)
+		wantErr bool
+	}{
+		{"same", func(e *LockEntry) {}, false},
+		{"version", func(e *LockEntry) { e.Version = "v1.2.4" }, true},
+		{"sum", func(e *LockEntry) { e.Sum = "h1:def=" }, true},
+		{"module", func(e *LockEntry) { e.Module = "github.com/fork/libreria-a" }, true},
+		{"replace", func(e *LockEntry) { e.Replace = "../libreria-a" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod := locked
+			tt.mutate(&mod)
+			if err := checkLockDrift(mod, locked, defaultLockFile); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGoSumHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.sum")
+	sum := `github.com/japablazatww/libreria-a v1.2.3 h1:zip=
+github.com/japablazatww/libreria-a v1.2.3/go.mod h1:mod=
+github.com/japablazatww/libreria-a v1.2.2 h1:old=
+`
+	if err := os.WriteFile(path, []byte(sum), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		module, version string
+		want            string
+		wantErr         bool
+	}{
+		{"github.com/japablazatww/libreria-a", "v1.2.3", "h1:zip=", false},
+		{"github.com/japablazatww/libreria-a", "v1.2.2", "h1:old=", false},
+		{"github.com/japablazatww/libreria-a", "v1.3.0", "", true},
+		{"github.com/japablazatww/libreria-b", "v1.2.3", "", true},
+	}
+	for _, tt := range tests {
+		got, err := goSumHash(path, tt.module, tt.version)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("goSumHash(%s@%s) = %q, %v; want %q, error %v", tt.module, tt.version, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -1,64 +1,147 @@
 package main
 
 import (
-	_ "embed"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"unicode"
-)
 
-//go:embed registry.json
-var registryData []byte
+	"golang.org/x/tools/go/packages"
+)
 
 // --- Structs ---
 
 type FunctionMetadata struct {
 	Name           string
+	Receiver       string // Service type for methods (e.g. BankService), empty for functions
+	Path           string // HTTP route, /liba/Transfer or /liba/BankService/Transfer
 	Params         []Param
 	Returns        []string
+	Results        []Param // Returned values excluding a trailing error, as response fields
+	ReturnsError   bool    // Last return value is an error
+	FlattenParams  bool    // Single struct parameter: its fields may be sent as params
 	RequestStruct  string
 	ResponseStruct string
 	Comment        string
 }
 
+// CanFail reports whether the generated call can fail before or in the
+// library: params to extract, a service to build or an error returned.
+func (f FunctionMetadata) CanFail() bool {
+	return len(requestParams(f.Params)) > 0 || f.Receiver != "" || f.ReturnsError
+}
+
 type Param struct {
-	Name      string
-	Type      string
-	JSONTag   string
-	FieldName string // PascalCase for struct
+	Name       string
+	Type       string
+	GoType     string // Type as written from the generated package (e.g. liba.Account)
+	Imports    []Import
+	SDKType    string // Type as written in the SDK, using DTOs instead of library types
+	SDKImports []Import
+	Variadic   bool
+	Context    bool // context.Context, injected from the HTTP request
+	Struct     bool // Type is a library struct (or pointer to one)
+	Embedded   bool // Embedded struct field (DTOs only)
+	JSONTag    string
+	FieldName  string // PascalCase for struct
+	Comment    string
 }
 
+// ServiceMetadata is an exported library type with a New<Type> constructor
+// whose exported methods are served from a singleton instance.
+type ServiceMetadata struct {
+	Name               string // BankService
+	GoType             string // liba.BankService
+	FieldName          string // LibreriaABankService (Services field and SDK client prefix)
+	Constructor        string // NewBankService
+	ConstructorArgs    int
+	ConstructorPointer bool // Constructor returns *T rather than T
+	ConstructorError   bool // Constructor also returns an error
+}
+
+// StructMetadata is an exported library struct mirrored as a DTO in the SDK.
+type StructMetadata struct {
+	Name     string // Account
+	TypeName string // LibreriaAAccount, the DTO in the SDK
+	Comment  string
+	Fields   []Param
+}
+
+// Catalog is the index written by build. The header fields describe the
+// build that produced it (see CatalogSchemaVersion).
 type Catalog struct {
-	Services []ServiceEntry `json:"services"`
+	SchemaVersion int             `json:"schema_version"`
+	MigratedFrom  int             `json:"migrated_from,omitempty"` // Schema version of the file, when older
+	GeneratedAt   string          `json:"generated_at,omitempty"`  // RFC 3339, UTC
+	CLIVersion    string          `json:"cli_version,omitempty"`
+	GoVersion     string          `json:"go_version,omitempty"`
+	Modules       []ModuleVersion `json:"modules"`
+	Services      []ServiceEntry  `json:"services"`
+	Types         []TypeEntry     `json:"types"`
+	Errors        []ErrorEntry    `json:"errors"`
+}
+
+// TypeEntry describes an exported struct type of a library.
+type TypeEntry struct {
+	Namespace   string          `json:"namespace"`
+	Package     string          `json:"package,omitempty"` // Import path of the library
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Fields      []FieldMetadata `json:"fields"`
+}
+
+type FieldMetadata struct {
+	Name        string          `json:"name"`    // Key used in JSON
+	GoName      string          `json:"go_name"` // Go field name
+	Type        string          `json:"type"`
+	TypeInfo    *TypeDescriptor `json:"type_info,omitempty"`
+	Embedded    bool            `json:"embedded,omitempty"`
+	Description string          `json:"description,omitempty"`
 }
 
 type ServiceEntry struct {
 	Namespace   string          `json:"namespace"`
+	Version     string          `json:"version,omitempty"`  // Module version the entry was indexed from
+	Receiver    string          `json:"receiver,omitempty"` // Service type of methods
 	Method      string          `json:"method"`
+	Path        string          `json:"path,omitempty"` // HTTP route of the generated server, none for methods only described
 	Description string          `json:"description"`
 	Inputs      []ParamMetadata `json:"inputs"`
 	Outputs     []ParamMetadata `json:"outputs"`
 }
 
+// FullMethod returns Method, prefixed with the receiver for service methods.
+func (s ServiceEntry) FullMethod() string {
+	if s.Receiver != "" {
+		return s.Receiver + "." + s.Method
+	}
+	return s.Method
+}
+
 type ParamMetadata struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	TypeInfo *TypeDescriptor `json:"type_info,omitempty"`
 }
 
 type SearchResult struct {
-	Namespace    string
-	Method       string
-	MatchedParam string
-	ParamType    string // "Input" or "Output"
+	Namespace    string       `json:"namespace"`
+	Method       string       `json:"method"`
+	MatchedParam string       `json:"matched_param,omitempty"`
+	ParamType    string       `json:"param_type,omitempty"` // "Input" or "Output"
+	Score        int          `json:"score"`                // Ranking, higher is a closer match
+	Service      ServiceEntry `json:"service"`
 }
 
 // --- Main ---
@@ -70,115 +153,189 @@ func main() {
 	// 1. Build
 	buildCmd := flag.NewFlagSet("build", flag.ExitOnError)
 	buildDebug := buildCmd.Bool("debug", false, "Enable verbose output")
+	buildOut := buildCmd.String("out", "nexus/generated", "Directory where server, SDK and types are generated (empty to only update the catalog)")
+	buildPkg := buildCmd.String("package", "generated", "Go package name of the generated code")
+	buildOffline := buildCmd.Bool("offline", false, "Use only the module cache (GOPROXY=off); versions come from "+defaultLockFile+" when present")
+	buildLocked := buildCmd.Bool("locked", false, "Build exactly the versions of "+defaultLockFile+" and fail if anything drifted")
+	buildErrors := buildCmd.String("errors", "", "JSON file overriding the HTTP status and code of library errors (default "+defaultErrorsFile+" when present)")
+	buildOutput := addOutputFlags(buildCmd)
 
 	// 2. Search
 	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
-	searchParam := searchCmd.String("search-param", "", "Search service by parameter name")
+	searchParam := searchCmd.String("search-param", "", "Search service by parameter name (fuzzy)")
+	searchType := searchCmd.String("type", "", "Search service by parameter type, e.g. float64")
+	searchMethod := searchCmd.String("method", "", "Search service by method name (fuzzy)")
+	searchText := searchCmd.String("text", "", "Search service by words of its doc comment")
+	searchIn := searchCmd.Bool("in", false, "Match --search-param/--type against inputs only")
+	searchOut := searchCmd.Bool("out", false, "Match --search-param/--type against outputs only")
 	searchDebug := searchCmd.Bool("debug", false, "Enable verbose output")
+	searchOutput := addOutputFlags(searchCmd)
+	searchQuery := func() SearchQuery {
+		return SearchQuery{
+			Param:   *searchParam,
+			Type:    *searchType,
+			Method:  *searchMethod,
+			Text:    *searchText,
+			Inputs:  *searchIn,
+			Outputs: *searchOut,
+		}
+	}
 
 	// 3. Dump
 	dumpCmd := flag.NewFlagSet("dump-catalog", flag.ExitOnError)
 	dumpDebug := dumpCmd.Bool("debug", false, "Enable verbose output")
+	dumpOutput := addOutputFlags(dumpCmd)
 
 	if len(os.Args) < 2 {
 		fmt.Println("Usage: nexus-cli <command> [arguments]")
-		fmt.Println("Commands: build, search, dump-catalog")
+		fmt.Println("Commands: build, search, dump-catalog, catalog, diff, export, registry")
 		os.Exit(1)
 	}
 
 	switch os.Args[1] {
 	case "build":
 		buildCmd.Parse(os.Args[2:])
-		runBuild(*buildDebug)
+		buildOutput.apply()
+		result := runBuild(BuildOptions{
+			OutDir:     *buildOut,
+			Package:    *buildPkg,
+			ErrorsFile: *buildErrors,
+			LockFile:   defaultLockFile,
+			Locked:     *buildLocked,
+			Offline:    *buildOffline,
+			Progress:   buildOutput.Progress(),
+			Debug:      *buildDebug,
+		})
+		if !buildOutput.Text() {
+			printBuildResult(*buildOutput, result)
+		}
 	case "search":
 		searchCmd.Parse(os.Args[2:])
-		runSearch(*searchParam, *searchDebug)
+		searchOutput.apply()
+		runSearch(searchQuery(), *searchOutput, *searchDebug)
 	case "dump-catalog":
 		dumpCmd.Parse(os.Args[2:])
-		runDump(*dumpDebug)
+		dumpOutput.apply()
+		runDump(*dumpOutput, *dumpDebug)
+	case "registry":
+		runRegistry(os.Args[2:])
+	case "catalog":
+		runCatalog(os.Args[2:])
+	case "diff":
+		runDiff(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
 	default:
 		// Smart-Run search?
 		if strings.HasPrefix(os.Args[1], "-") {
 			searchCmd.Parse(os.Args[1:])
-			runSearch(*searchParam, *searchDebug)
+			searchOutput.apply()
+			runSearch(searchQuery(), *searchOutput, *searchDebug)
 		} else {
-			fmt.Println("Unknown command. Expected 'build', 'search', or 'dump-catalog'.")
+			fmt.Println("Unknown command. Expected 'build', 'search', 'dump-catalog', 'catalog', 'diff', 'export' or 'registry'.")
 			os.Exit(1)
 		}
 	}
 }
 
-func runDump(debug bool) {
+func runDump(out OutputOptions, debug bool) {
 	path := resolveDefaultCatalog()
 	if debug {
-		fmt.Printf("Reading catalog from: %s\n", path)
+		fmt.Fprintf(out.Progress(), "Reading catalog from: %s\n", path)
 	}
-	data, err := os.ReadFile(path)
+	catalog, err := readCatalogFile(path)
 	if err != nil {
-		fmt.Printf("Error reading catalog: %v\n", err)
+		fmt.Fprintf(out.Progress(), "Error reading catalog: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Println(string(data))
+	if out.Text() {
+		out.Format = "json"
+	}
+	output := Output{Value: catalog, Headers: []string{"Service", "Version", "Inputs", "Outputs", "Description"}}
+	for _, s := range catalog.Services {
+		id := s.Namespace + "." + s.FullMethod()
+		output.Rows = append(output.Rows, []string{id, s.Version, paramList(s.Inputs), paramList(s.Outputs), s.Description})
+		output.IDs = append(output.IDs, id)
+	}
+	out.print(output)
 }
 
 // --- Search Logic ---
 
-func runSearch(query string, debug bool) {
+func runSearch(query SearchQuery, out OutputOptions, debug bool) {
+	w := out.Progress()
+
 	// 1. Resolve Catalog Path
 	catalogPath := resolveDefaultCatalog()
 	if debug {
-		fmt.Printf("DEBUG: Using catalog path: %s\n", catalogPath)
+		fmt.Fprintf(w, "DEBUG: Using catalog path: %s\n", catalogPath)
 	}
 
 	// 2. Auto-Discovery Check
 	data, err := os.ReadFile(catalogPath)
 	if err != nil {
-		fmt.Println("Catalog not found. Running auto-discovery...")
-		runBuild(debug) // Propagate debug
+		fmt.Fprintln(w, "Catalog not found. Running auto-discovery...")
+		runBuild(BuildOptions{Progress: w, Debug: debug}) // Catalog only, never touch generated code
 		// Re-read
 		data, err = os.ReadFile(catalogPath)
 		if err != nil {
-			fmt.Printf("Error: Could not build catalog: %v\n", err)
+			fmt.Fprintf(w, "Error: Could not build catalog: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
 	// 3. Parse Catalog
-	var catalog Catalog
-	if err := json.Unmarshal(data, &catalog); err != nil {
-		fmt.Printf("Error parsing catalog: %v\n", err)
+	catalog, err := loadCatalog(data)
+	if err != nil {
+		fmt.Fprintf(w, "Error parsing catalog: %v\n", err)
 		// If data exists but is bad invalid json, maybe print it in debug
 		if debug {
-			fmt.Printf("DEBUG: Invalid JSON content:\n%s\n", string(data))
+			fmt.Fprintf(w, "DEBUG: Invalid JSON content:\n%s\n", string(data))
 		}
 		os.Exit(1)
 	}
 
 	if debug {
-		fmt.Printf("DEBUG: Catalog loaded. %d services found.\n", len(catalog.Services))
+		fmt.Fprintf(w, "DEBUG: Catalog loaded. %d services found.\n", len(catalog.Services))
 	}
 
 	// 4. Search Execution
-	if query != "" {
+	if !out.Text() {
+		results := searchCatalog(catalog, query)
+		output := Output{
+			Value:   SearchResponse{Query: query, Count: len(results), Results: results},
+			Headers: []string{"Service", "Match", "Param Type", "Score", "Description"},
+		}
+		for _, res := range results {
+			id := res.Namespace + "." + res.Method
+			output.Rows = append(output.Rows, []string{id, res.MatchedParam, res.ParamType, strconv.Itoa(res.Score), res.Service.Description})
+			output.IDs = append(output.IDs, id)
+		}
+		out.print(output)
+	} else if !query.Empty() {
 		if debug {
-			fmt.Printf("DEBUG: Searching for param '%s'...\n", query)
+			fmt.Fprintf(w, "DEBUG: Searching with %+v...\n", query)
 		}
-		results := searchByParam(catalog, query)
-		// ... (rest is same logic, just keeping signatures consistent)
+		results := searchCatalog(catalog, query)
 		if len(results) == 0 {
-			fmt.Println("No services found with that parameter.")
+			fmt.Println("No services found.")
 		} else {
-			fmt.Printf("Found %d services with parameter '%s':\n", len(results), query)
+			fmt.Printf("Found %d services:\n", len(results))
 			for _, res := range results {
 				fmt.Printf("- %s.%s\n", res.Namespace, res.Method)
-				fmt.Printf("  Match: %s (%s)\n", res.MatchedParam, res.ParamType)
+				if res.MatchedParam != "" {
+					fmt.Printf("  Match: %s (%s)\n", res.MatchedParam, res.ParamType)
+				}
+				if debug {
+					fmt.Printf("  DEBUG: score %d\n", res.Score)
+				}
 			}
 		}
 	} else {
 		// List all by default
 		fmt.Println("Available Services:")
 		for _, s := range catalog.Services {
-			fmt.Printf("- %s.%s\n  %s\n", s.Namespace, s.Method, s.Description)
+			fmt.Printf("- %s.%s\n  %s\n", s.Namespace, s.FullMethod(), s.Description)
 			if len(s.Inputs) > 0 {
 				fmt.Println("  Inputs:")
 				for _, in := range s.Inputs {
@@ -195,37 +352,6 @@ func runSearch(query string, debug bool) {
 	}
 }
 
-func searchByParam(catalog Catalog, query string) []SearchResult {
-	var results []SearchResult
-	normalizedQuery := normalize(query)
-
-	for _, svc := range catalog.Services {
-		// Check Inputs
-		for _, param := range svc.Inputs {
-			if normalize(param.Name) == normalizedQuery {
-				results = append(results, SearchResult{
-					Namespace:    svc.Namespace,
-					Method:       svc.Method,
-					MatchedParam: param.Name,
-					ParamType:    "Input",
-				})
-			}
-		}
-		// Check Outputs
-		for _, param := range svc.Outputs {
-			if normalize(param.Name) == normalizedQuery {
-				results = append(results, SearchResult{
-					Namespace:    svc.Namespace,
-					Method:       svc.Method,
-					MatchedParam: param.Name,
-					ParamType:    "Output",
-				})
-			}
-		}
-	}
-	return results
-}
-
 func normalize(s string) string {
 	return strings.ToLower(strings.ReplaceAll(s, "_", ""))
 }
@@ -240,8 +366,33 @@ func resolveDefaultCatalog() string {
 
 // --- Build / Index Logic ---
 
-func runBuild(debug bool) {
-	fmt.Println("Starting Nexus Library Discovery...")
+// BuildOptions controls what runBuild produces besides the global catalog.
+type BuildOptions struct {
+	OutDir     string    // Target directory for generated code; empty skips generation
+	Package    string    // Package name of the generated code
+	ErrorsFile string    // Error mapping overrides; empty uses defaultErrorsFile if present
+	LockFile   string    // Lockfile written with the resolved versions; empty skips it
+	Locked     bool      // Install the LockFile versions and fail on any drift
+	Offline    bool      // Resolve modules from the module cache only
+	Progress   io.Writer // Progress and debug messages; nil writes them to stdout
+	Debug      bool
+}
+
+func runBuild(opts BuildOptions) BuildResult {
+	debug := opts.Debug
+	w := opts.Progress
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintln(w, "Starting Nexus Library Discovery...")
+
+	if opts.Offline {
+		// Every go command below inherits these. The checksum database is
+		// unreachable too; cached modules were verified when downloaded.
+		os.Setenv("GOFLAGS", "-mod=mod")
+		os.Setenv("GOPROXY", "off")
+		os.Setenv("GOSUMDB", "off")
+	}
 
 	// Create Temp Dir for safe go get execution
 	tempDir, err := os.MkdirTemp("", "nexus-build")
@@ -251,51 +402,165 @@ func runBuild(debug bool) {
 	defer os.RemoveAll(tempDir) // Clean up
 
 	if debug {
-		fmt.Printf("DEBUG: Temp build dir: %s\n", tempDir)
+		fmt.Fprintf(w, "DEBUG: Temp build dir: %s\n", tempDir)
 	}
 
 	// init temp module
 	execCmd(tempDir, "go", "mod", "init", "nexus-temp-builder")
 
-	var libraries []string
-	if err := json.Unmarshal(registryData, &libraries); err != nil {
-		log.Fatalf("Error parsing internal registry: %v", err)
+	libraries, err := loadRegistry()
+	if err != nil {
+		log.Fatalf("Error reading registry: %v", err)
+	}
+
+	overrides, err := loadErrorOverrides(opts.ErrorsFile)
+	if err != nil {
+		log.Fatalf("Error reading error overrides: %v", err)
+	}
+
+	// A locked build installs the versions of the lockfile and fails on any
+	// difference instead of resolving the registry versions again.
+	lock, err := readBuildLockfile(opts.LockFile, opts.Locked, opts.Offline)
+	if err != nil {
+		log.Fatalf("Error reading lockfile: %v", err)
+	}
+	if opts.Locked {
+		if err := checkLockedRegistry(lock, libraries, opts.LockFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+	resolved := Lockfile{Libraries: []LockEntry{}}
+	var replaces []moduleReplace
+	skip := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, format, args...)
+		if opts.Locked {
+			os.Exit(1) // Leaving a library out is drift too
+		}
 	}
 
-	var allMetadata []FunctionMetadata
-	var catalog Catalog
+	var allMetadata []LibraryMetadata
+	catalog := newCatalog()
 
-	for _, lib := range libraries {
-		fmt.Printf("Checking library: %s ... ", lib)
+	for _, entry := range libraries {
+		lib := entry.Path
+		if entry.Disabled {
+			if debug {
+				fmt.Fprintf(w, "DEBUG: Skipping disabled library %s (%s registry)\n", lib, entry.Source)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "Checking library: %s ... ", lib)
+
+		version := installVersion(entry, lock)
+		locked, ok := lock.Find(lib)
+		if opts.Locked && !ok {
+			skip("Failed: not in %s, run build without --locked to update it\n", opts.LockFile)
+		}
 
 		// 1. Ensure Installed (in temp module context)
-		if err := ensureLibraryInstalled(tempDir, lib, debug); err != nil {
-			fmt.Printf("Failed: %v\n", err)
+		if entry.Replace != "" {
+			rep, err := replaceLibrary(tempDir, entry, w, debug)
+			if err != nil {
+				skip("Failed: %v\n", err)
+				continue
+			}
+			replaces = append(replaces, rep)
+		} else if err := ensureLibraryInstalled(tempDir, lib, version, w, debug); err != nil {
+			skip("Failed: %v\n", err)
+			continue
+		}
+		mod, err := resolveModule(tempDir, lib)
+		if err != nil {
+			skip("Failed: %v\n", err)
 			continue
 		}
+		mod.Replace = entry.Replace
+		if opts.Locked {
+			if err := checkLockDrift(mod, locked, opts.LockFile); err != nil {
+				skip("Failed: %v\n", err)
+			}
+		}
+		resolved.Libraries = append(resolved.Libraries, mod)
+		catalog.Modules = append(catalog.Modules, ModuleVersion{Path: mod.Module, Version: mod.Version})
 
-		// 2. Resolve Path (using go list in temp context)
-		path, err := resolvePackagePath(tempDir, lib, debug)
+		// 2. Load and type-check (using go list in temp context)
+		pkg, err := loadLibrary(tempDir, lib, w, debug)
 		if err != nil {
-			fmt.Printf("Error resolving path: %v\n", err)
+			skip("Error loading %s: %v\n", lib, err)
 			continue
 		}
-		if debug {
-			fmt.Printf("DEBUG: Resolved path for %s: %s\n", lib, path)
-		} else {
-			fmt.Println("OK")
+		if !debug {
+			fmt.Fprintln(w, "OK")
 		}
 
-		// 3. Parse AST
-		meta, entries := parseLibrary(path, lib, debug)
+		// 3. Index exported functions
+		meta, entries, typeEntries := parseLibrary(pkg, entry, w, debug)
+		errs, errorEntries := indexErrors(pkg, meta, overrides[meta.Namespace], w, debug)
+		meta.Errors = errs
+		for i := range entries {
+			entries[i].Version = mod.Version
+		}
 		if debug {
-			fmt.Printf("DEBUG: Parsed %d functions from %s\n", len(entries), lib)
+			fmt.Fprintf(w, "DEBUG: Parsed %d functions from %s@%s\n", len(entries), lib, mod.Version)
+		}
+		if len(meta.Functions) > 0 {
+			allMetadata = append(allMetadata, meta)
 		}
-		allMetadata = append(allMetadata, meta...)
 		catalog.Services = append(catalog.Services, entries...)
+		catalog.Types = append(catalog.Types, typeEntries...)
+		catalog.Errors = append(catalog.Errors, errorEntries...)
+	}
+
+	result := BuildResult{Catalog: updateGlobalCatalog(catalog, w), OutDir: opts.OutDir, Modules: resolved.Libraries}
+	for _, s := range catalog.Services {
+		result.Services = append(result.Services, s.Namespace+"."+s.FullMethod())
+	}
+
+	if opts.LockFile != "" && !opts.Locked {
+		if err := writeLockfile(opts.LockFile, resolved); err != nil {
+			log.Fatalf("Error writing lockfile: %v", err)
+		}
+		fmt.Fprintf(w, "Success. Lockfile updated: %s\n", opts.LockFile)
+	}
+
+	if opts.OutDir != "" {
+		if err := generateCode(opts.OutDir, opts.Package, allMetadata, catalog, w, debug); err != nil {
+			log.Fatalf("Error generating code: %v", err)
+		}
+		if len(replaces) > 0 {
+			if err := updateProjectModule(opts.OutDir, replaces, w); err != nil {
+				log.Fatalf("Error updating go.mod: %v", err)
+			}
+			fmt.Fprintln(w, "Run 'go mod tidy' if the replaced libraries need new dependencies.")
+		}
+		if err := checkGeneratedCode(opts.OutDir); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		fmt.Fprintf(w, "Success. Code generated in: %s\n", opts.OutDir)
 	}
+	return result
+}
 
-	updateGlobalCatalog(catalog)
+// BuildResult is what build reports with --output.
+type BuildResult struct {
+	Catalog  string      `json:"catalog"`
+	OutDir   string      `json:"out_dir,omitempty"`
+	Modules  []LockEntry `json:"modules"`
+	Services []string    `json:"services"`
+}
+
+func printBuildResult(out OutputOptions, result BuildResult) {
+	if result.Modules == nil {
+		result.Modules = []LockEntry{}
+	}
+	if result.Services == nil {
+		result.Services = []string{}
+	}
+	output := Output{Value: result, Headers: []string{"Path", "Module", "Version", "Sum"}, IDs: result.Services}
+	for _, m := range result.Modules {
+		output.Rows = append(output.Rows, []string{m.Path, m.Module, m.Version, m.Sum})
+	}
+	out.print(output)
 }
 
 func execCmd(dir string, name string, args ...string) error {
@@ -306,10 +571,10 @@ func execCmd(dir string, name string, args ...string) error {
 	return cmd.Run()
 }
 
-func ensureLibraryInstalled(widthDir string, pkg string, debug bool) error {
-	// go get pkg@latest
+func ensureLibraryInstalled(widthDir string, pkg string, version string, w io.Writer, debug bool) error {
+	// go get pkg@version
 	// stderr capture for better error reporting
-	cmd := exec.Command("go", "get", pkg+"@latest")
+	cmd := exec.Command("go", "get", pkg+"@"+version)
 	cmd.Dir = widthDir
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -317,129 +582,463 @@ func ensureLibraryInstalled(widthDir string, pkg string, debug bool) error {
 		return fmt.Errorf("error running go get: %s\nOutput: %s", err, string(output))
 	}
 	if debug {
-		fmt.Printf("\nDEBUG: go get output:\n%s\n", string(output))
+		fmt.Fprintf(w, "\nDEBUG: go get output:\n%s\n", string(output))
 	}
 	return nil
 }
 
-func resolvePackagePath(withDir string, pkg string, debug bool) (string, error) {
-	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkg)
-	cmd.Dir = withDir
-	output, err := cmd.Output()
+// loadLibrary type-checks pkg from the temp module. Test files are excluded
+// and build constraints are evaluated for the current GOOS/GOARCH.
+// Dependencies are type-checked from source (NeedDeps) rather than read from
+// export data, whose format is tied to the installed Go toolchain.
+func loadLibrary(withDir string, pkg string, w io.Writer, debug bool) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:   withDir,
+		Tests: false,
+	}
+	pkgs, err := packages.Load(cfg, pkg)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("error loading package: %w", err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected 1 package for %s, found %d", pkg, len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		var msgs []string
+		for _, e := range pkgs[0].Errors {
+			msgs = append(msgs, "  "+e.Error())
+		}
+		return nil, fmt.Errorf("%d type errors:\n%s", len(msgs), strings.Join(msgs, "\n"))
 	}
-	path := strings.TrimSpace(string(output))
 	if debug {
-		fmt.Printf("DEBUG: Raw path bytes: %x\n", path)
+		fmt.Fprintf(w, "DEBUG: Loaded %s from %v\n", pkgs[0].PkgPath, pkgs[0].GoFiles)
 	}
-	return path, nil
+	return pkgs[0], nil
 }
 
-func parseLibrary(path string, namespace string, debug bool) ([]FunctionMetadata, []ServiceEntry) {
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, path, nil, parser.ParseComments)
-	if err != nil {
-		log.Printf("Warning: error parsing %s: %v", path, err)
-		return nil, nil
+var errorType = types.Universe.Lookup("error").Type()
+
+func parseLibrary(pkg *packages.Package, entry RegistryEntry, w io.Writer, debug bool) (LibraryMetadata, []ServiceEntry, []TypeEntry) {
+	namespace := entry.NamespaceName()
+	lib := LibraryMetadata{
+		ImportPath:  entry.Path,
+		PackageName: pkg.Name,
+		Namespace:   namespace,
+		ClientName:  toExportedName(namespace),
 	}
+
+	var entries []ServiceEntry
+
 	if debug {
-		fmt.Printf("DEBUG: ParseDir found %d packages in %s\n", len(pkgs), path)
+		fmt.Fprintf(w, "DEBUG: Visiting package %s\n", pkg.Name)
 	}
 
-	var metadata []FunctionMetadata
-	var entries []ServiceEntry
+	structs, typeEntries := indexStructs(pkg, lib, w, debug)
+	lib.Structs = structs
+	dtos := map[string]bool{}
+	for _, st := range structs {
+		dtos[st.Name] = true
+	}
 
-	for _, pkg := range pkgs {
+	lib.Services = findServices(pkg, lib)
+	services := map[string]ServiceMetadata{}
+	constructors := map[string]bool{}
+	for _, svc := range lib.Services {
 		if debug {
-			fmt.Printf("DEBUG: Visiting package %s\n", pkg.Name)
+			fmt.Fprintf(w, "DEBUG: Found service type %s (constructor %s)\n", svc.Name, svc.Constructor)
 		}
-		for _, file := range pkg.Files {
-			if debug {
-				fmt.Printf("DEBUG: Visiting file in %s\n", pkg.Name)
+		services[svc.Name] = svc
+		constructors[svc.Constructor] = true
+	}
+
+	for _, file := range pkg.Syntax {
+		if debug {
+			fmt.Fprintf(w, "DEBUG: Visiting file %s\n", pkg.Fset.File(file.Pos()).Name())
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !fn.Name.IsExported() {
+				continue
 			}
-			for _, decl := range file.Decls {
-				if fn, ok := decl.(*ast.FuncDecl); ok {
-					if !fn.Name.IsExported() {
-						continue
-					}
+			obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			sig := obj.Type().(*types.Signature)
+			fname := fn.Name.Name
+
+			// Methods are only served for service types; constructors of
+			// service types build the singletons and are not endpoints.
+			receiver := ""
+			if recv := sig.Recv(); recv != nil {
+				name := receiverTypeName(recv.Type())
+				if _, ok := services[name]; !ok {
 					if debug {
-						fmt.Printf("DEBUG: Found exported func %s\n", fn.Name.Name)
+						fmt.Fprintf(w, "DEBUG: Skipping method %s.%s (not a service type)\n", name, fname)
 					}
+					continue
+				}
+				receiver = name
+			} else if constructors[fname] {
+				continue
+			}
+			if debug {
+				fmt.Fprintf(w, "DEBUG: Found exported func %s\n", strings.TrimPrefix(receiver+"."+fname, "."))
+			}
 
-					fname := fn.Name.Name
-
-					// Inputs
-					inputs := []ParamMetadata{}
-					params := []Param{}
-					for _, field := range fn.Type.Params.List {
-						typeExpr := typeToString(field.Type)
-						for _, name := range field.Names {
-							pName := name.Name
-							// Add to internal params (for server gen compat if needed later)
-							params = append(params, Param{
-								Name:      pName,
-								Type:      typeExpr,
-								JSONTag:   toSnakeCase(pName),
-								FieldName: toPascalCase(pName),
-							})
-							// Add to Catalog Inputs
-							inputs = append(inputs, ParamMetadata{
-								Name: toSnakeCase(pName),
-								Type: typeExpr,
-							})
-						}
-					}
+			path := "/" + lib.PackageName + "/" + fname
+			if receiver != "" {
+				path = "/" + lib.PackageName + "/" + receiver + "/" + fname
+			}
+			// Set when a signature uses a type the generated package cannot name.
+			generatable := sig.TypeParams().Len() == 0
 
-					// Outputs
-					returns := []string{}
-					outputs := []ParamMetadata{}
-					if fn.Type.Results != nil {
-						for i, field := range fn.Type.Results.List {
-							typeExpr := typeToString(field.Type)
-							// Return values often don't have names, or share types
-							// We make best effort to label them if multiple
-							// If named returns, we use them. Else "ret0", "ret1" or request user spec?
-							// For PoC: just show types.
-							name := ""
-							if len(field.Names) > 0 {
-								for _, n := range field.Names {
-									name = n.Name
-									outputs = append(outputs, ParamMetadata{Name: name, Type: typeExpr})
-								}
-							} else {
-								// Unnamed return
-								name = fmt.Sprintf("result_%d", i)
-								outputs = append(outputs, ParamMetadata{Name: name, Type: typeExpr})
-							}
-							returns = append(returns, typeExpr)
-						}
-					}
+			// Inputs
+			inputs := []ParamMetadata{}
+			params := []Param{}
+			for i := 0; i < sig.Params().Len(); i++ {
+				v := sig.Params().At(i)
+				pName := v.Name()
+				if pName == "" || pName == "_" {
+					pName = fmt.Sprintf("arg%d", i)
+				}
+				variadic := sig.Variadic() && i == sig.Params().Len()-1
 
-					meta := FunctionMetadata{
-						Name:          fname,
-						Params:        params,
-						Returns:       returns,
-						RequestStruct: fname + "Request",
-						Comment:       fn.Doc.Text(),
-					}
-					metadata = append(metadata, meta)
-
-					entries = append(entries, ServiceEntry{
-						Namespace:   strings.TrimPrefix(namespace, "github.com/japablazatww/"),
-						Method:      fname,
-						Description: strings.TrimSpace(fn.Doc.Text()),
-						Inputs:      inputs,
-						Outputs:     outputs,
-					})
+				// context.Context comes from the HTTP request, not from params.
+				if isContextType(v.Type()) {
+					params = append(params, Param{Name: pName, Type: "context.Context", Context: true})
+					continue
+				}
+
+				typeExpr := typeToString(v.Type(), pkg.Types)
+				typeInfo := describeType(v.Type())
+				if variadic {
+					elem := v.Type().(*types.Slice).Elem()
+					typeExpr = "..." + typeToString(elem, pkg.Types)
+					typeInfo = &TypeDescriptor{Kind: "variadic", Elem: describeType(elem)}
+				}
+				goType, typeImports, ok := goTypeString(v.Type(), lib)
+				generatable = generatable && ok
+				sdkType, sdkImports := sdkTypeString(v.Type(), lib, dtos)
+
+				// Add to internal params (for server gen compat if needed later)
+				params = append(params, Param{
+					Name:       pName,
+					Type:       typeExpr,
+					GoType:     goType,
+					Imports:    typeImports,
+					SDKType:    sdkType,
+					SDKImports: sdkImports,
+					Variadic:   variadic,
+					Struct:     isLibraryStruct(v.Type(), dtos, pkg.Types),
+					JSONTag:    toSnakeCase(pName),
+					FieldName:  toPascalCase(pName),
+				})
+				// Add to Catalog Inputs
+				inputs = append(inputs, ParamMetadata{
+					Name:     toSnakeCase(pName),
+					Type:     typeExpr,
+					TypeInfo: typeInfo,
+				})
+			}
+
+			// Outputs
+			// Named returns keep their names, unnamed ones are labelled result_N.
+			returns := []string{}
+			outputs := []ParamMetadata{}
+			values := []Param{}
+			for i := 0; i < sig.Results().Len(); i++ {
+				v := sig.Results().At(i)
+				name := v.Name()
+				if name == "" || name == "_" {
+					name = fmt.Sprintf("result_%d", i)
 				}
+				typeExpr := typeToString(v.Type(), pkg.Types)
+				goType, typeImports, ok := goTypeString(v.Type(), lib)
+				generatable = generatable && ok
+				sdkType, sdkImports := sdkTypeString(v.Type(), lib, dtos)
+
+				outputs = append(outputs, ParamMetadata{Name: name, Type: typeExpr, TypeInfo: describeType(v.Type())})
+				values = append(values, Param{
+					Name: name, Type: typeExpr,
+					GoType: goType, Imports: typeImports,
+					SDKType: sdkType, SDKImports: sdkImports,
+				})
+				returns = append(returns, typeExpr)
+			}
+			returnsError := sig.Results().Len() > 0 &&
+				types.Identical(sig.Results().At(sig.Results().Len()-1).Type(), errorType)
+			if returnsError {
+				values = values[:len(values)-1]
+			}
+
+			meta := FunctionMetadata{
+				Name:           fname,
+				Receiver:       receiver,
+				Path:           path,
+				Params:         params,
+				Returns:        returns,
+				Results:        resultFields(values),
+				ReturnsError:   returnsError,
+				FlattenParams:  len(inputs) == 1 && requestParams(params)[0].Struct,
+				RequestStruct:  lib.ClientName + receiver + fname + "Request",
+				ResponseStruct: lib.ClientName + receiver + fname + "Response",
+				Comment:        fn.Doc.Text(),
+			}
+			// Generic functions need explicit instantiation and unexported
+			// types cannot be named from the generated package, so those
+			// functions are only described in the catalog.
+			if generatable {
+				lib.Functions = append(lib.Functions, meta)
+			} else if debug {
+				fmt.Fprintf(w, "DEBUG: Skipping code generation for %s\n", fname)
 			}
+
+			entry := ServiceEntry{
+				Namespace:   namespace,
+				Receiver:    receiver,
+				Method:      fname,
+				Description: strings.TrimSpace(fn.Doc.Text()),
+				Inputs:      inputs,
+				Outputs:     outputs,
+			}
+			if generatable {
+				entry.Path = path
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return lib, entries, typeEntries
+}
+
+// findServices returns the exported, non-generic types T of the library that
+// have exported methods and a constructor NewT returning T or *T, optionally
+// followed by an error.
+func findServices(pkg *packages.Package, lib LibraryMetadata) []ServiceMetadata {
+	var services []ServiceMetadata
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		ctor, ok := scope.Lookup("New" + name).(*types.Func)
+		if !ok {
+			continue
+		}
+		sig := ctor.Type().(*types.Signature)
+		if sig.TypeParams().Len() > 0 || sig.Results().Len() == 0 || sig.Results().Len() > 2 {
+			continue
+		}
+		returnsError := sig.Results().Len() == 2
+		if returnsError && !types.Identical(sig.Results().At(1).Type(), errorType) {
+			continue
 		}
+		first := sig.Results().At(0).Type()
+		pointer := types.Identical(first, types.NewPointer(named))
+		if !pointer && !types.Identical(first, named) {
+			continue
+		}
+
+		exported := 0
+		mset := types.NewMethodSet(types.NewPointer(named))
+		for i := 0; i < mset.Len(); i++ {
+			if mset.At(i).Obj().Exported() {
+				exported++
+			}
+		}
+		if exported == 0 {
+			continue
+		}
+
+		services = append(services, ServiceMetadata{
+			Name:               name,
+			GoType:             lib.PackageName + "." + name,
+			FieldName:          lib.ClientName + name,
+			Constructor:        ctor.Name(),
+			ConstructorArgs:    sig.Params().Len(),
+			ConstructorPointer: pointer,
+			ConstructorError:   returnsError,
+		})
 	}
-	return metadata, entries
+	return services
+}
+
+// isContextType reports whether t is context.Context.
+func isContextType(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
 }
 
-func updateGlobalCatalog(cat Catalog) {
+// requestParams returns the params a client has to send (no context.Context).
+func requestParams(params []Param) []Param {
+	var out []Param
+	for _, p := range params {
+		if !p.Context {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// receiverTypeName returns the type name of a method receiver (T or *T).
+func receiverTypeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
+// indexStructs collects the exported, non-generic struct types of a library
+// with their exported fields, JSON keys and doc comments.
+func indexStructs(pkg *packages.Package, lib LibraryMetadata, w io.Writer, debug bool) ([]StructMetadata, []TypeEntry) {
+	type structDecl struct {
+		obj  *types.TypeName
+		node *ast.StructType
+		doc  string
+	}
+	var decls []structDecl
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				node, ok := ts.Type.(*ast.StructType)
+				if !ok || !ts.Name.IsExported() || ts.TypeParams != nil || ts.Assign.IsValid() {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+				if !ok {
+					continue
+				}
+				doc := ts.Doc.Text()
+				if doc == "" && len(gen.Specs) == 1 {
+					doc = gen.Doc.Text()
+				}
+				decls = append(decls, structDecl{obj: obj, node: node, doc: doc})
+			}
+		}
+	}
+
+	// All DTO names must be known before rendering fields that refer to them.
+	dtos := map[string]bool{}
+	for _, d := range decls {
+		dtos[d.obj.Name()] = true
+	}
+
+	var structs []StructMetadata
+	var entries []TypeEntry
+	for _, d := range decls {
+		if debug {
+			fmt.Fprintf(w, "DEBUG: Found exported struct %s\n", d.obj.Name())
+		}
+		st := d.obj.Type().Underlying().(*types.Struct)
+
+		// Field docs come from the AST, in declaration order.
+		fieldDocs := map[string]string{}
+		for _, f := range d.node.Fields.List {
+			doc := strings.TrimSpace(f.Doc.Text())
+			if doc == "" {
+				doc = strings.TrimSpace(f.Comment.Text())
+			}
+			for _, n := range f.Names {
+				fieldDocs[n.Name] = doc
+			}
+			if len(f.Names) == 0 {
+				fieldDocs[typeToString(pkg.TypesInfo.TypeOf(f.Type), pkg.Types)] = doc
+			}
+		}
+
+		meta := StructMetadata{Name: d.obj.Name(), TypeName: lib.ClientName + d.obj.Name(), Comment: d.doc}
+		entry := TypeEntry{
+			Namespace:   lib.Namespace,
+			Package:     lib.ImportPath,
+			Name:        d.obj.Name(),
+			Description: strings.TrimSpace(d.doc),
+			Fields:      []FieldMetadata{},
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			jsonKey, tag, skip := jsonFieldKey(f.Name(), st.Tag(i))
+			if skip {
+				continue
+			}
+			typeExpr := typeToString(f.Type(), pkg.Types)
+			sdkType, sdkImports := sdkTypeString(f.Type(), lib, dtos)
+			doc := fieldDocs[f.Name()]
+			if f.Embedded() {
+				doc = fieldDocs[typeExpr]
+			}
+
+			meta.Fields = append(meta.Fields, Param{
+				Name:       f.Name(),
+				Type:       typeExpr,
+				SDKType:    sdkType,
+				SDKImports: sdkImports,
+				Embedded:   f.Embedded() && tag == "",
+				JSONTag:    tag,
+				FieldName:  f.Name(),
+				Comment:    doc,
+			})
+			entry.Fields = append(entry.Fields, FieldMetadata{
+				Name:        jsonKey,
+				GoName:      f.Name(),
+				Type:        typeExpr,
+				TypeInfo:    describeType(f.Type()),
+				Embedded:    f.Embedded(),
+				Description: doc,
+			})
+		}
+		structs = append(structs, meta)
+		entries = append(entries, entry)
+	}
+	return structs, entries
+}
+
+// jsonFieldKey applies encoding/json naming to a struct field: the key used
+// on the wire, the raw json tag value to mirror, and whether it is skipped.
+func jsonFieldKey(name string, tag string) (string, string, bool) {
+	value, ok := reflect.StructTag(tag).Lookup("json")
+	if !ok {
+		return name, "", false
+	}
+	if value == "-" {
+		return "", "", true
+	}
+	key, _, _ := strings.Cut(value, ",")
+	if key == "" {
+		key = name
+	}
+	return key, value, false
+}
+
+// isLibraryStruct reports whether t is one of the library's DTO structs or a
+// pointer to one.
+func isLibraryStruct(t types.Type, dtos map[string]bool, lib *types.Package) bool {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() == lib && dtos[named.Obj().Name()]
+}
+
+func updateGlobalCatalog(cat Catalog, w io.Writer) string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.Fatal(err)
@@ -456,22 +1055,44 @@ func updateGlobalCatalog(cat Catalog) {
 	encGlobal := json.NewEncoder(fGlobal)
 	encGlobal.SetIndent("", "  ")
 	encGlobal.Encode(cat)
-	fmt.Printf("Success. Catalog updated: %s\n", filepath.Join(globalDir, "catalog.json"))
+	fmt.Fprintf(w, "Success. Catalog updated: %s\n", filepath.Join(globalDir, "catalog.json"))
+	return filepath.Join(globalDir, "catalog.json")
 }
 
 // --- Helpers ---
 
-func typeToString(expr ast.Expr) string {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		return "*" + typeToString(t.X)
-	case *ast.SelectorExpr:
-		return typeToString(t.X) + "." + t.Sel.Name
-	default:
-		return "interface{}"
+// typeToString renders a resolved type for the catalog: aliases are
+// expanded, types of the library itself are unqualified and types of other
+// packages use their package name (time.Duration).
+func typeToString(t types.Type, lib *types.Package) string {
+	var p typePrinter
+	p = typePrinter{named: func(t *types.Named) string {
+		obj := t.Obj()
+		if obj.Pkg() == nil || obj.Pkg() == lib {
+			return obj.Name() + p.typeArgs(t)
+		}
+		return obj.Pkg().Name() + "." + obj.Name() + p.typeArgs(t)
+	}}
+	return p.render(t)
+}
+
+// resultFields names the returned values (trailing error already removed)
+// for the JSON response. A single value keeps the historical "result" key;
+// multiple values use their named returns or the catalog's result_N names.
+func resultFields(values []Param) []Param {
+	if len(values) == 1 {
+		single := values[0]
+		single.Name, single.JSONTag, single.FieldName = "result", "result", "Result"
+		return []Param{single}
 	}
+
+	results := []Param{}
+	for _, v := range values {
+		v.JSONTag = toSnakeCase(v.Name)
+		v.FieldName = toExportedName(v.Name)
+		results = append(results, v)
+	}
+	return results
 }
 
 func toSnakeCase(str string) string {
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseSource parses src as the library github.com/japablazatww/libreria-test.
+func parseSource(t *testing.T, src string) (LibraryMetadata, []ServiceEntry, []TypeEntry) {
+	t.Helper()
+	pkg := checkSource(t, src)
+	return parseLibrary(pkg, RegistryEntry{Path: pkg.PkgPath}, io.Discard, false)
+}
+
+func findFunction(t *testing.T, lib LibraryMetadata, name string) FunctionMetadata {
+	t.Helper()
+	for _, fn := range lib.Functions {
+		if strings.TrimPrefix(fn.Receiver+"."+fn.Name, ".") == name {
+			return fn
+		}
+	}
+	t.Fatalf("no generated function %s", name)
+	return FunctionMetadata{}
+}
+
+func TestParseLibraryResults(t *testing.T) {
+	lib, entries, _ := parseSource(t, `package libtest
+
+func Nothing() {}
+
+func Fail() error { return nil }
+
+func Balance() float64 { return 0 }
+
+func BalanceOrError() (float64, error) { return 0, nil }
+
+func Pair() (int, string) { return 0, "" }
+
+func Named() (total float64, currency string, err error) { return }
+
+func Mixed() (count int, _ string) { return }
+`)
+	tests := []struct {
+		name         string
+		results      []string // JSONTag:GoType of the response fields
+		returnsError bool
+		outputs      []string // Catalog output names
+	}{
+		{"Nothing", nil, false, nil},
+		{"Fail", nil, true, []string{"result_0"}},
+		{"Balance", []string{"result:float64"}, false, []string{"result_0"}},
+		{"BalanceOrError", []string{"result:float64"}, true, []string{"result_0", "result_1"}},
+		{"Pair", []string{"result_0:int", "result_1:string"}, false, []string{"result_0", "result_1"}},
+		{"Named", []string{"total:float64", "currency:string"}, true, []string{"total", "currency", "err"}},
+		{"Mixed", []string{"count:int", "result_1:string"}, false, []string{"count", "result_1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := findFunction(t, lib, tt.name)
+			var results []string
+			for _, r := range fn.Results {
+				results = append(results, r.JSONTag+":"+r.GoType)
+			}
+			if !reflect.DeepEqual(results, tt.results) {
+				t.Errorf("results %v, want %v", results, tt.results)
+			}
+			if fn.ReturnsError != tt.returnsError {
+				t.Errorf("ReturnsError = %v, want %v", fn.ReturnsError, tt.returnsError)
+			}
+			for _, e := range entries {
+				if e.Method != tt.name {
+					continue
+				}
+				var outputs []string
+				for _, o := range e.Outputs {
+					outputs = append(outputs, o.Name)
+				}
+				if !reflect.DeepEqual(outputs, tt.outputs) {
+					t.Errorf("catalog outputs %v, want %v", outputs, tt.outputs)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseFieldsMatchResults(t *testing.T) {
+	// diff and the OpenAPI spec key responses like the generated handlers.
+	lib, entries, _ := parseSource(t, `package libtest
+
+func Balance() (float64, error) { return 0, nil }
+
+func Named() (total float64, currencyCode string, err error) { return }
+`)
+	for _, e := range entries {
+		fn := findFunction(t, lib, e.Method)
+		keys := responseFields(e.Outputs)
+		if len(keys) != len(fn.Results) {
+			t.Fatalf("%s: response keys %v, generated results %+v", e.Method, keys, fn.Results)
+		}
+		for _, r := range fn.Results {
+			if _, ok := keys[r.JSONTag]; !ok {
+				t.Errorf("%s: generated key %s missing from %v", e.Method, r.JSONTag, keys)
+			}
+		}
+	}
+}
+
+func TestIndexStructs(t *testing.T) {
+	_, _, types := parseSource(t, `package libtest
+
+import "time"
+
+// Base holds the common fields.
+type Base struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+// Account is a bank account.
+type Account struct {
+	*Base
+	// Owner is the holder.
+	Owner   string    `+"`json:\"owner,omitempty\"`"+`
+	Balance float64   // In cents
+	Opened  time.Time `+"`json:\"opened\"`"+`
+	Secret  string    `+"`json:\"-\"`"+`
+	private int
+}
+
+type (
+	Empty struct{}
+	Pair[T any] struct{ A, B T }
+	Alias = Account
+	status struct{ Code int }
+)
+`)
+	got := map[string]TypeEntry{}
+	for _, te := range types {
+		got[te.Name] = te
+	}
+	if len(got) != 3 || got["Base"].Name == "" || got["Account"].Name == "" || got["Empty"].Name == "" {
+		t.Fatalf("indexed types %v, want Base, Account and Empty", got)
+	}
+
+	account := got["Account"]
+	if account.Description != "Account is a bank account." || account.Package != "github.com/japablazatww/libreria-test" {
+		t.Errorf("Account entry %+v", account)
+	}
+	var fields []string
+	for _, f := range account.Fields {
+		s := f.Name + ":" + f.Type
+		if f.Embedded {
+			s += ":embedded"
+		}
+		if f.Description != "" {
+			s += " // " + f.Description
+		}
+		fields = append(fields, s)
+	}
+	want := []string{
+		"Base:*Base:embedded",
+		"owner:string // Owner is the holder.",
+		"Balance:float64 // In cents",
+		"opened:time.Time",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Account fields\n%q\nwant\n%q", fields, want)
+	}
+}
+
+func TestIndexStructsDTOs(t *testing.T) {
+	lib, _, _ := parseSource(t, `package libtest
+
+type Base struct{ ID string }
+
+type Account struct {
+	*Base
+	Owner string `+"`json:\"owner,omitempty\"`"+`
+	Tags  []*Base
+}
+
+func Open(a Account) error { return nil }
+`)
+	var account StructMetadata
+	for _, st := range lib.Structs {
+		if st.Name == "Account" {
+			account = st
+		}
+	}
+	var fields []string
+	for _, f := range account.Fields {
+		fields = append(fields, f.FieldName+" "+f.SDKType+" "+f.JSONTag)
+	}
+	want := []string{"Base *LibreriaTestBase ", "Owner string owner,omitempty", "Tags []*LibreriaTestBase "}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("DTO fields %q, want %q", fields, want)
+	}
+	if account.TypeName != "LibreriaTestAccount" {
+		t.Errorf("DTO type %s, want LibreriaTestAccount", account.TypeName)
+	}
+	if !account.Fields[0].Embedded {
+		t.Errorf("Base is not embedded in the DTO")
+	}
+
+	open := findFunction(t, lib, "Open")
+	if !open.FlattenParams || !open.Params[0].Struct || open.Params[0].SDKType != "LibreriaTestAccount" {
+		t.Errorf("Open(a Account) params %+v, flatten %v", open.Params, open.FlattenParams)
+	}
+}
+
+func TestJSONFieldKey(t *testing.T) {
+	tests := []struct {
+		name, tag string
+		key, raw  string
+		skip      bool
+	}{
+		{"Owner", "", "Owner", "", false},
+		{"Owner", `json:"owner"`, "owner", "owner", false},
+		{"Owner", `json:"owner,omitempty"`, "owner", "owner,omitempty", false},
+		{"Owner", `json:",omitempty"`, "Owner", ",omitempty", false},
+		{"Owner", `json:"-"`, "", "", true},
+		{"Owner", `json:"-,"`, "-", "-,", false},
+		{"Owner", `yaml:"owner"`, "Owner", "", false},
+	}
+	for _, tt := range tests {
+		key, raw, skip := jsonFieldKey(tt.name, tt.tag)
+		if key != tt.key || raw != tt.raw || skip != tt.skip {
+			t.Errorf("jsonFieldKey(%q, %q) = %q, %q, %v; want %q, %q, %v", tt.name, tt.tag, key, raw, skip, tt.key, tt.raw, tt.skip)
+		}
+	}
+}
+
+func TestFindServices(t *testing.T) {
+	lib, entries, _ := parseSource(t, `package libtest
+
+import "errors"
+
+type BankService struct{}
+
+func NewBankService() *BankService { return &BankService{} }
+
+func (s *BankService) Transfer(amount float64) error { return nil }
+
+func (s *BankService) audit() {}
+
+type Ledger struct{ dsn string }
+
+func NewLedger(dsn string) (Ledger, error) { return Ledger{}, errors.New("no db") }
+
+func (l Ledger) Balance() float64 { return 0 }
+
+type NoMethods struct{}
+
+func NewNoMethods() *NoMethods { return nil }
+
+type NoConstructor struct{}
+
+func (n NoConstructor) Run() {}
+
+type BadConstructor struct{}
+
+func NewBadConstructor() (*BadConstructor, int) { return nil, 0 }
+
+func (b *BadConstructor) Run() {}
+`)
+	want := []ServiceMetadata{
+		{Name: "BankService", GoType: "libtest.BankService", FieldName: "LibreriaTestBankService", Constructor: "NewBankService", ConstructorPointer: true},
+		{Name: "Ledger", GoType: "libtest.Ledger", FieldName: "LibreriaTestLedger", Constructor: "NewLedger", ConstructorArgs: 1, ConstructorError: true},
+	}
+	if !reflect.DeepEqual(lib.Services, want) {
+		t.Errorf("services\n%+v\nwant\n%+v", lib.Services, want)
+	}
+
+	var methods []string
+	for _, e := range entries {
+		methods = append(methods, e.Namespace+"."+e.FullMethod()+" "+e.Path)
+	}
+	wantMethods := []string{
+		"libreria-test.BankService.Transfer /libtest/BankService/Transfer",
+		"libreria-test.Ledger.Balance /libtest/Ledger/Balance",
+		"libreria-test.NewNoMethods /libtest/NewNoMethods",
+		"libreria-test.NewBadConstructor /libtest/NewBadConstructor",
+	}
+	if !reflect.DeepEqual(methods, wantMethods) {
+		t.Errorf("catalog entries\n%q\nwant\n%q", methods, wantMethods)
+	}
+	if fn := findFunction(t, lib, "Ledger.Balance"); !fn.CanFail() {
+		t.Errorf("a service method can fail building its service")
+	}
+}
+
+func TestParseLibraryContext(t *testing.T) {
+	lib, entries, _ := parseSource(t, `package libtest
+
+import "context"
+
+type Ctx = context.Context
+
+func Status(ctx context.Context) string { return "" }
+
+func Transfer(ctx Ctx, amount float64) error { return nil }
+
+func Lookup(id string, _ context.Context) string { return "" }
+`)
+	tests := []struct {
+		name    string
+		params  []string // Params the client sends
+		context []bool   // Of every generated param
+		canFail bool
+	}{
+		{"Status", nil, []bool{true}, false},
+		{"Transfer", []string{"amount"}, []bool{true, false}, true},
+		{"Lookup", []string{"id"}, []bool{false, true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := findFunction(t, lib, tt.name)
+			var params []string
+			for _, p := range requestParams(fn.Params) {
+				params = append(params, p.Name)
+			}
+			var context []bool
+			for _, p := range fn.Params {
+				context = append(context, p.Context)
+			}
+			if !reflect.DeepEqual(params, tt.params) || !reflect.DeepEqual(context, tt.context) {
+				t.Errorf("request params %v, context %v; want %v, %v", params, context, tt.params, tt.context)
+			}
+			if fn.CanFail() != tt.canFail {
+				t.Errorf("CanFail = %v, want %v", fn.CanFail(), tt.canFail)
+			}
+			if fn.FlattenParams {
+				t.Errorf("context.Context is not a struct param")
+			}
+		})
+	}
+	for _, e := range entries {
+		for _, in := range e.Inputs {
+			if strings.Contains(in.Type, "Context") {
+				t.Errorf("%s: context param %s listed in the catalog", e.Method, in.Name)
+			}
+		}
+	}
+}
+
+func TestLoadLibrary(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go":       "package lib\n\nimport \"example.com/tmp/dep\"\n\nfunc Rate() dep.Rate { return 1 }\n",
+		"lib/lib_test.go":  "package lib\n\nfunc helper() {}\n",
+		"lib/ignored.go":   "//go:build ignore\n\npackage lib\n\nfunc Rate() int { return 2 }\n",
+		"dep/dep.go":       "package dep\n\ntype Rate float64\n",
+		"broken/broken.go": "package broken\n\nfunc F() int { return \"1\" }\n\nfunc G() { undefined() }\n",
+	})
+	t.Setenv("GOPROXY", "off") // The missing package is not looked up
+	tests := []struct {
+		pkg     string
+		wantErr string
+	}{
+		{"example.com/tmp/lib", ""},
+		{"example.com/tmp/broken", "2 type errors"},
+		{"example.com/tmp/missing", "example.com/tmp/missing"},
+		{"example.com/tmp/...", "expected 1 package for example.com/tmp/..., found 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			pkg, err := loadLibrary(dir, tt.pkg, io.Discard, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Neither the test file nor the ignored file are loaded, and the
+			// dependency resolves to the module's own package.
+			if len(pkg.GoFiles) != 1 || !strings.HasSuffix(pkg.GoFiles[0], "lib.go") {
+				t.Errorf("loaded %v, want lib.go only", pkg.GoFiles)
+			}
+			rate := pkg.Types.Scope().Lookup("Rate")
+			if rate == nil || rate.Type().String() != "func() example.com/tmp/dep.Rate" {
+				t.Errorf("Rate is %v, want a func returning example.com/tmp/dep.Rate", rate)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// moduleReplace is a replace directive a build needs in the server's go.mod.
+type moduleReplace struct {
+	Module  string // Replaced module path
+	Dir     string // Absolute local directory, or
+	Target  string // replacement module path
+	Version string // and version
+}
+
+// replaceLibrary makes the temp module build entry from its Replace: a local
+// checkout or another module version. The placeholder requirement lets the
+// go command resolve the module without asking a proxy for it.
+func replaceLibrary(withDir string, entry RegistryEntry, w io.Writer, debug bool) (moduleReplace, error) {
+	rep := moduleReplace{Module: entry.Path}
+	target := entry.Replace
+	if entry.Dir != "" {
+		data, err := os.ReadFile(filepath.Join(entry.Dir, "go.mod"))
+		if err != nil {
+			return rep, fmt.Errorf("error reading local checkout: %w", err)
+		}
+		if rep.Module = modfile.ModulePath(data); rep.Module == "" {
+			return rep, fmt.Errorf("no module declared in %s", filepath.Join(entry.Dir, "go.mod"))
+		}
+		if !strings.HasPrefix(entry.Path+"/", rep.Module+"/") {
+			return rep, fmt.Errorf("%s is not part of module %s in %s", entry.Path, rep.Module, entry.Dir)
+		}
+		rep.Dir, target = entry.Dir, entry.Dir
+	} else {
+		var ok bool
+		if rep.Target, rep.Version, ok = strings.Cut(entry.Replace, "@"); !ok {
+			return rep, fmt.Errorf("replace %q must be a local directory or module@version", entry.Replace)
+		}
+	}
+
+	steps := [][]string{
+		{"mod", "edit", "-replace=" + rep.Module + "=" + target, "-require=" + rep.Module + "@" + placeholderVersion},
+		{"get", rep.Module + "@" + placeholderVersion},
+	}
+	for _, args := range steps {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = withDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return rep, fmt.Errorf("error running go %s: %s\nOutput: %s", args[0], err, string(output))
+		}
+		if debug {
+			fmt.Fprintf(w, "\nDEBUG: go %s output:\n%s\n", args[0], string(output))
+		}
+	}
+	return rep, nil
+}
+
+// updateProjectModule adds the replace directives of replaced libraries to
+// the go.mod enclosing outDir, so the generated server builds against the
+// same code that was indexed. Local directories are written relative to it.
+func updateProjectModule(outDir string, replaces []moduleReplace, w io.Writer) error {
+	dir, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no go.mod found above %s", outDir)
+		}
+		dir = parent
+	}
+	gomod := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return err
+	}
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rep := range replaces {
+		target, version := rep.Target, rep.Version
+		if rep.Dir != "" {
+			rel, err := filepath.Rel(dir, rep.Dir)
+			if err != nil {
+				return err
+			}
+			target = filepath.ToSlash(rel)
+			if !strings.HasPrefix(target, "../") {
+				target = "./" + target
+			}
+		}
+		if err := f.AddReplace(rep.Module, "", target, version); err != nil {
+			return err
+		}
+		required := false
+		for _, r := range f.Require {
+			required = required || r.Mod.Path == rep.Module
+		}
+		if !required {
+			if err := f.AddRequire(rep.Module, placeholderVersion); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(w, "Success. %s: replace %s => %s\n", gomod, rep.Module, strings.TrimSpace(target+" "+version))
+	}
+
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(gomod, out, 0644)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+func TestUpdateProjectModule(t *testing.T) {
+	root := t.TempDir()
+	gomod := filepath.Join(root, "go.mod")
+	initial := "module example.com/server\n\ngo 1.23\n\nrequire github.com/japablazatww/libreria-a v1.0.0\n"
+	if err := os.WriteFile(gomod, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(root, "nexus", "generated")
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	replaces := []moduleReplace{
+		{Module: "github.com/japablazatww/libreria-a", Dir: filepath.Join(filepath.Dir(root), "libreria-a")},
+		{Module: "github.com/japablazatww/libreria-b", Dir: filepath.Join(root, "vendor-libs", "libreria-b")},
+		{Module: "github.com/japablazatww/libreria-c", Target: "github.com/fork/libreria-c", Version: "v1.2.3"},
+	}
+	// Twice: a rebuild must not duplicate directives.
+	for i := 0; i < 2; i++ {
+		if err := updateProjectModule(outDir, replaces, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReplace := map[string]string{
+		"github.com/japablazatww/libreria-a": "../libreria-a",
+		"github.com/japablazatww/libreria-b": "./vendor-libs/libreria-b",
+		"github.com/japablazatww/libreria-c": "github.com/fork/libreria-c v1.2.3",
+	}
+	if len(f.Replace) != len(wantReplace) {
+		t.Errorf("%d replace directives, want %d:\n%s", len(f.Replace), len(wantReplace), data)
+	}
+	for _, r := range f.Replace {
+		if got := strings.TrimSpace(r.New.Path + " " + r.New.Version); got != wantReplace[r.Old.Path] {
+			t.Errorf("replace %s => %s, want %s", r.Old.Path, got, wantReplace[r.Old.Path])
+		}
+	}
+	wantRequire := map[string]string{
+		"github.com/japablazatww/libreria-a": "v1.0.0", // Already required
+		"github.com/japablazatww/libreria-b": placeholderVersion,
+		"github.com/japablazatww/libreria-c": placeholderVersion,
+	}
+	if len(f.Require) != len(wantRequire) {
+		t.Errorf("%d requirements, want %d:\n%s", len(f.Require), len(wantRequire), data)
+	}
+	for _, r := range f.Require {
+		if r.Mod.Version != wantRequire[r.Mod.Path] {
+			t.Errorf("require %s %s, want %s", r.Mod.Path, r.Mod.Version, wantRequire[r.Mod.Path])
+		}
+	}
+}
+
+func TestUpdateProjectModuleWithoutGoMod(t *testing.T) {
+	err := updateProjectModule(t.TempDir(), []moduleReplace{{Module: "m", Target: "n", Version: "v1.0.0"}}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "no go.mod found") {
+		t.Fatalf("error = %v, want no go.mod found", err)
+	}
+}
+
+func TestReplaceLibraryInvalid(t *testing.T) {
+	checkout := t.TempDir()
+	if err := os.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module github.com/japablazatww/libreria-a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+	if err := os.WriteFile(filepath.Join(empty, "go.mod"), []byte("go 1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   RegistryEntry
+		wantErr string
+	}{
+		{"module without version", RegistryEntry{Path: "github.com/japablazatww/libreria-a", Replace: "github.com/fork/libreria-a"},
+			"must be a local directory or module@version"},
+		{"directory without go.mod", RegistryEntry{Path: "github.com/japablazatww/libreria-a", Replace: "./missing", Dir: filepath.Join(checkout, "missing")},
+			"error reading local checkout"},
+		{"go.mod without module", RegistryEntry{Path: "github.com/japablazatww/libreria-a", Replace: "./empty", Dir: empty},
+			"no module declared"},
+		{"other module", RegistryEntry{Path: "github.com/japablazatww/libreria-b", Replace: "./libreria-a", Dir: checkout},
+			"is not part of module github.com/japablazatww/libreria-a"},
+		{"module prefix", RegistryEntry{Path: "github.com/japablazatww/libreria-ab", Replace: "./libreria-a", Dir: checkout},
+			"is not part of module github.com/japablazatww/libreria-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := replaceLibrary(t.TempDir(), tt.entry, io.Discard, false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
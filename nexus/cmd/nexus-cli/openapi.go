@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification written by
+// export openapi and served by the generated server at /openapi.json.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIDocument is the subset of an OpenAPI 3.1 document nexus-cli writes.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+	Modules    []ModuleVersion            `json:"x-nexus-modules,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIPathItem struct {
+	Post *OpenAPIOperation `json:"post,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is a JSON Schema (2020-12) object, as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Examples             []interface{}      `json:"examples,omitempty"`
+	GoType               string             `json:"x-go-type,omitempty"`
+}
+
+// openAPIDocument describes the routes of the generated server for the
+// services of cat. Services without a route are described in the catalog
+// only (generic functions, unexported types) and are left out.
+func openAPIDocument(cat Catalog) OpenAPIDocument {
+	doc := OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info: OpenAPIInfo{
+			Title:   "Nexus API",
+			Version: catalogHash(cat),
+			Description: "Every library function is served as POST <route> with its arguments in the \"params\" object. " +
+				"Param keys are matched ignoring case and underscores, so user_id, userId and UserID are the same key.",
+		},
+		Paths:      map[string]OpenAPIPathItem{},
+		Components: OpenAPIComponents{Schemas: map[string]*Schema{"Error": errorSchema()}},
+		Modules:    cat.Modules,
+	}
+
+	types := map[string]TypeEntry{}
+	for _, t := range cat.Types {
+		types[t.Namespace+"."+t.Name] = t
+	}
+	for _, t := range cat.Types {
+		doc.Components.Schemas[schemaName(t.Namespace, t.Name)] = typeSchema(t, types)
+	}
+	errorsByNamespace := map[string][]ErrorEntry{}
+	for _, e := range cat.Errors {
+		errorsByNamespace[e.Namespace] = append(errorsByNamespace[e.Namespace], e)
+	}
+
+	for _, svc := range cat.Services {
+		if svc.Path == "" {
+			continue
+		}
+		op := &OpenAPIOperation{
+			OperationID: svc.Namespace + "." + svc.FullMethod(),
+			Summary:     strings.SplitN(svc.Description, "\n", 2)[0],
+			Description: svc.Description,
+			Tags:        []string{svc.Namespace},
+			RequestBody: &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{"application/json": {Schema: requestSchema(svc, types)}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": {
+					Description: "Values returned by " + svc.FullMethod(),
+					Content:     map[string]OpenAPIMediaType{"application/json": {Schema: responseSchema(svc, types)}},
+				},
+			},
+		}
+		addErrorResponses(op, svc, errorsByNamespace[svc.Namespace])
+		doc.Paths[svc.Path] = OpenAPIPathItem{Post: op}
+	}
+	return doc
+}
+
+// schemaName is the component name of a library type: LibreriaAAccount.
+func schemaName(namespace, name string) string {
+	return toExportedName(namespace) + name
+}
+
+// requestSchema describes the {"params": {...}} envelope of svc. Each param
+// is listed under its catalog key and the camelCase and PascalCase spellings
+// the server also accepts; one of them is required.
+func requestSchema(svc ServiceEntry, types map[string]TypeEntry) *Schema {
+	params := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, in := range svc.Inputs {
+		schema := paramSchema(in.Type, in.TypeInfo, svc.Namespace, types)
+		params.Properties[in.Name] = schema
+		keys := []string{in.Name}
+		for _, alias := range keyAliases(in.Name) {
+			params.Properties[alias] = &Schema{AllOf: []*Schema{schema}, Description: "Same as " + in.Name + "."}
+			keys = append(keys, alias)
+		}
+		if len(keys) == 1 {
+			params.Required = append(params.Required, in.Name)
+			continue
+		}
+		anyKey := &Schema{}
+		for _, k := range keys {
+			anyKey.AnyOf = append(anyKey.AnyOf, &Schema{Required: []string{k}})
+		}
+		params.AllOf = append(params.AllOf, anyKey)
+	}
+
+	// A single struct param may also be sent as the params object itself.
+	if len(svc.Inputs) == 1 {
+		if ref := libraryTypeRef(svc.Inputs[0].TypeInfo, svc.Namespace, types); ref != nil {
+			params = &Schema{AnyOf: []*Schema{params, ref}}
+		}
+	}
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"params": params},
+		Required:   []string{"params"},
+	}
+}
+
+// keyAliases returns the camelCase and PascalCase spellings of a snake_case
+// key that differ from it.
+func keyAliases(key string) []string {
+	parts := strings.Split(key, "_")
+	for i, p := range parts {
+		parts[i] = toPascalCase(p)
+	}
+	pascal := strings.Join(parts, "")
+	if pascal == "" {
+		return nil
+	}
+	camel := strings.ToLower(pascal[:1]) + pascal[1:]
+
+	var aliases []string
+	for _, a := range []string{camel, pascal} {
+		if a != key && !containsString(aliases, a) {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
+
+// responseSchema describes the successful response of svc, keyed the way
+// the generated handlers write it (see responseFields).
+func responseSchema(svc ServiceEntry, types map[string]TypeEntry) *Schema {
+	outputs := svc.Outputs
+	if returnsError(outputs) {
+		outputs = outputs[:len(outputs)-1]
+	}
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, out := range outputs {
+		key := toSnakeCase(out.Name)
+		if len(outputs) == 1 {
+			key = "result"
+		}
+		schema.Properties[key] = paramSchema(out.Type, out.TypeInfo, svc.Namespace, types)
+		schema.Required = append(schema.Required, key)
+	}
+	return schema
+}
+
+// addErrorResponses lists the error envelopes op can answer with: request
+// errors, recovered panics and open circuits, the 503 of unconfigured
+// services and, for methods returning an error, the library's mapped errors
+// and the 500 fallback.
+func addErrorResponses(op *OpenAPIOperation, svc ServiceEntry, libErrors []ErrorEntry) {
+	codes := map[int][]string{
+		http.StatusBadRequest:          {"invalid_request", "missing_param", "invalid_param"},
+		http.StatusMethodNotAllowed:    {"method_not_allowed"},
+		http.StatusInternalServerError: {"internal_error"},
+		http.StatusServiceUnavailable:  {"circuit_open"},
+	}
+	if svc.Receiver != "" {
+		codes[http.StatusServiceUnavailable] = append(codes[http.StatusServiceUnavailable], "service_unavailable")
+	}
+	if returnsError(svc.Outputs) {
+		codes[http.StatusInternalServerError] = append(codes[http.StatusInternalServerError], "library_error")
+		for _, e := range libErrors {
+			codes[e.Status] = append(codes[e.Status], e.Code)
+		}
+	}
+	for status, list := range codes {
+		sort.Strings(list)
+		op.Responses[strconv.Itoa(status)] = OpenAPIResponse{
+			Description: http.StatusText(status) + ": " + strings.Join(list, ", "),
+			Content: map[string]OpenAPIMediaType{"application/json": {Schema: &Schema{
+				AllOf: []*Schema{
+					{Ref: "#/components/schemas/Error"},
+					{Properties: map[string]*Schema{"error": {Properties: map[string]*Schema{"code": {Enum: list}}}}},
+				},
+			}}},
+		}
+	}
+}
+
+// errorSchema is the {"error": ...} envelope of failed calls.
+func errorSchema() *Schema {
+	str := func(desc string) *Schema { return &Schema{Type: "string", Description: desc} }
+	return &Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*Schema{"error": {
+			Type:     "object",
+			Required: []string{"code", "message"},
+			Properties: map[string]*Schema{
+				"code":           str("Stable error code, e.g. missing_param or a library code"),
+				"message":        str("Human-readable description"),
+				"parameter":      str("Param that failed, for missing_param and invalid_param"),
+				"library":        str("Catalog namespace of the called library"),
+				"method":         str("Called method, Type.Method for service methods"),
+				"correlation_id": str("Identifies the logged stack of an internal_error"),
+				"details":        {Type: "object", Description: "Expected and received types of invalid params"},
+			},
+		}},
+	}
+}
+
+// typeSchema describes a library struct by its JSON fields.
+func typeSchema(t TypeEntry, types map[string]TypeEntry) *Schema {
+	schema := &Schema{Type: "object", Description: t.Description, Properties: map[string]*Schema{}}
+	for _, f := range t.Fields {
+		fs := paramSchema(f.Type, f.TypeInfo, t.Namespace, types)
+		if f.Embedded && fs.Ref != "" {
+			// encoding/json promotes the fields of embedded structs.
+			schema.AllOf = append(schema.AllOf, fs)
+			continue
+		}
+		if f.Description != "" && fs.Ref == "" {
+			fs.Description = f.Description
+		}
+		schema.Properties[f.Name] = fs
+	}
+	return schema
+}
+
+// paramSchema returns the JSON schema of a catalog type, falling back to an
+// unconstrained schema annotated with the Go type.
+func paramSchema(goType string, info *TypeDescriptor, namespace string, types map[string]TypeEntry) *Schema {
+	if info == nil {
+		return &Schema{GoType: goType}
+	}
+	schema := descriptorSchema(info, namespace, types)
+	if schema.Ref == "" && schema.Type == "" && schema.GoType == "" {
+		schema.GoType = goType
+	}
+	return schema
+}
+
+func descriptorSchema(d *TypeDescriptor, namespace string, types map[string]TypeEntry) *Schema {
+	switch d.Kind {
+	case "basic":
+		return basicSchema(d.Name)
+	case "named":
+		if ref := libraryTypeRef(d, namespace, types); ref != nil {
+			return ref
+		}
+		switch d.Package + "." + d.Name {
+		case "time.Time":
+			return &Schema{Type: "string", Format: "date-time"}
+		case "time.Duration":
+			return &Schema{
+				AnyOf:       []*Schema{{Type: "string"}, {Type: "integer"}},
+				Description: "Duration such as 1m30s, or nanoseconds",
+				Examples:    []interface{}{"1m30s"},
+			}
+		}
+		return &Schema{GoType: d.Package + "." + d.Name}
+	case "pointer":
+		return &Schema{AnyOf: []*Schema{descriptorSchema(d.Elem, namespace, types), {Type: "null"}}}
+	case "slice", "variadic", "array":
+		if d.Elem != nil && d.Elem.Kind == "basic" && (d.Elem.Name == "byte" || d.Elem.Name == "uint8") && d.Kind == "slice" {
+			return &Schema{Type: "string", Format: "byte", Description: "Base64 encoded bytes"}
+		}
+		schema := &Schema{Type: "array", Items: descriptorSchema(d.Elem, namespace, types)}
+		if n, err := strconv.Atoi(d.Len); err == nil && d.Kind == "array" {
+			schema.MinItems, schema.MaxItems = &n, &n
+		}
+		return schema
+	case "map":
+		return &Schema{Type: "object", AdditionalProperties: descriptorSchema(d.Elem, namespace, types)}
+	case "struct":
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, f := range d.Fields {
+			name := f.Name
+			if tag, _, _ := strings.Cut(reflect.StructTag(f.Tag).Get("json"), ","); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			schema.Properties[name] = descriptorSchema(f.Type, namespace, types)
+		}
+		return schema
+	}
+	// interface, type_param, chan and func: anything the coercion accepts.
+	return &Schema{}
+}
+
+// libraryType returns the catalog entry of the struct d names when it is a
+// type of the library of namespace: same name and same import path.
+func libraryType(d *TypeDescriptor, namespace string, types map[string]TypeEntry) (TypeEntry, bool) {
+	if d == nil || d.Kind != "named" {
+		return TypeEntry{}, false
+	}
+	t, ok := types[namespace+"."+d.Name]
+	return t, ok && t.Package == d.Package
+}
+
+// libraryTypeRef returns a reference to the component of a library struct.
+func libraryTypeRef(d *TypeDescriptor, namespace string, types map[string]TypeEntry) *Schema {
+	if _, ok := libraryType(d, namespace, types); !ok {
+		return nil
+	}
+	return &Schema{Ref: "#/components/schemas/" + schemaName(namespace, d.Name)}
+}
+
+func basicSchema(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int64", "uint", "uint64", "uintptr":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "int8", "int16", "int32", "rune", "uint8", "uint16", "uint32", "byte":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	}
+	return &Schema{GoType: name} // complex numbers, error, any
+}
+
+// --- export subcommand ---
+
+// runExport writes a description of the catalog in another format. The
+// OpenAPI document and nexus.proto are the ones build writes next to the
+// generated server.
+func runExport(args []string) {
+	if len(args) == 0 || (args[0] != "openapi" && args[0] != "proto") {
+		fmt.Println("Usage: nexus-cli export openapi|proto [--file catalog.json] [--output json|yaml|table|markdown]")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("export "+args[0], flag.ExitOnError)
+	file := cmd.String("file", "", "Catalog to export (default ~/.nexus/catalog.json)")
+	out := addOutputFlags(cmd)
+	cmd.Parse(args[1:])
+	out.apply()
+
+	path := *file
+	if path == "" {
+		path = resolveDefaultCatalog()
+	}
+	catalog, err := readCatalogFile(path)
+	if err != nil {
+		fmt.Fprintf(out.Progress(), "Error reading catalog: %v\n", err)
+		os.Exit(1)
+	}
+	if args[0] == "proto" {
+		runExportProto(catalog, *out)
+		return
+	}
+	doc := openAPIDocument(catalog)
+	if len(doc.Paths) == 0 && len(catalog.Services) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: the catalog has no routes, rebuild it with this nexus-cli")
+	}
+
+	if out.Text() || out.Format == "json" && !out.Quiet {
+		// Byte for byte the document served at /openapi.json.
+		data, err := openAPIJSON(catalog)
+		if err != nil {
+			fmt.Fprintf(out.Progress(), "Error encoding OpenAPI document: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	output := Output{Value: doc, Headers: []string{"Route", "Operation", "Responses"}}
+	routes := make([]string, 0, len(doc.Paths))
+	for route := range doc.Paths {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		op := doc.Paths[route].Post
+		statuses := make([]string, 0, len(op.Responses))
+		for s := range op.Responses {
+			statuses = append(statuses, s)
+		}
+		sort.Strings(statuses)
+		output.Rows = append(output.Rows, []string{"POST " + route, op.OperationID, strings.Join(statuses, " ")})
+		output.IDs = append(output.IDs, op.OperationID)
+	}
+	out.print(output)
+}
+
+// openAPIJSON is the document build writes as openapi.json.
+func openAPIJSON(cat Catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(openAPIDocument(cat)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testLibPath = "github.com/japablazatww/libreria-a"
+
+var testTypes = map[string]TypeEntry{
+	"libreria-a.Account": {Namespace: "libreria-a", Package: testLibPath, Name: "Account"},
+}
+
+func TestLibraryType(t *testing.T) {
+	tests := []struct {
+		name string
+		d    *TypeDescriptor
+		ok   bool
+	}{
+		{"library struct", &TypeDescriptor{Kind: "named", Name: "Account", Package: testLibPath}, true},
+		{"same name in another package", &TypeDescriptor{Kind: "named", Name: "Account", Package: "github.com/other/bank"}, false},
+		{"other name", &TypeDescriptor{Kind: "named", Name: "Wallet", Package: testLibPath}, false},
+		{"pointer", &TypeDescriptor{Kind: "pointer", Elem: &TypeDescriptor{Kind: "named", Name: "Account", Package: testLibPath}}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := libraryType(tt.d, "libreria-a", testTypes); ok != tt.ok {
+				t.Errorf("libraryType = %v, want %v", ok, tt.ok)
+			}
+			if ref := libraryTypeRef(tt.d, "libreria-a", testTypes); (ref != nil) != tt.ok {
+				t.Errorf("libraryTypeRef = %+v, want a ref %v", ref, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDescriptorSchema(t *testing.T) {
+	named := func(pkg, name string) *TypeDescriptor {
+		return &TypeDescriptor{Kind: "named", Name: name, Package: pkg}
+	}
+	basic := func(name string) *TypeDescriptor { return &TypeDescriptor{Kind: "basic", Name: name} }
+	two := 2
+	tests := []struct {
+		name string
+		d    *TypeDescriptor
+		want *Schema
+	}{
+		{"string", basic("string"), &Schema{Type: "string"}},
+		{"int", basic("int"), &Schema{Type: "integer", Format: "int64"}},
+		{"uint8", basic("uint8"), &Schema{Type: "integer", Format: "int32"}},
+		{"float64", basic("float64"), &Schema{Type: "number", Format: "double"}},
+		{"complex128", basic("complex128"), &Schema{GoType: "complex128"}},
+		{"time", named("time", "Time"), &Schema{Type: "string", Format: "date-time"}},
+		{"duration", named("time", "Duration"), &Schema{
+			AnyOf:       []*Schema{{Type: "string"}, {Type: "integer"}},
+			Description: "Duration such as 1m30s, or nanoseconds",
+			Examples:    []interface{}{"1m30s"},
+		}},
+		{"library struct", named(testLibPath, "Account"), &Schema{Ref: "#/components/schemas/LibreriaAAccount"}},
+		{"foreign struct", named("github.com/other/bank", "Account"), &Schema{GoType: "github.com/other/bank.Account"}},
+		{"pointer", &TypeDescriptor{Kind: "pointer", Elem: basic("string")}, &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "null"}}}},
+		{"bytes", &TypeDescriptor{Kind: "slice", Elem: basic("byte")}, &Schema{Type: "string", Format: "byte", Description: "Base64 encoded bytes"}},
+		{"byte array", &TypeDescriptor{Kind: "array", Len: "2", Elem: basic("byte")}, &Schema{Type: "array", Items: &Schema{Type: "integer", Format: "int32"}, MinItems: &two, MaxItems: &two}},
+		{"map", &TypeDescriptor{Kind: "map", Key: basic("string"), Elem: basic("bool")}, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "boolean"}}},
+		{"inline struct", &TypeDescriptor{Kind: "struct", Fields: []FieldDescriptor{
+			{Name: "ID", Type: basic("string"), Tag: `json:"id,omitempty"`},
+			{Name: "Secret", Type: basic("string"), Tag: `json:"-"`},
+			{Name: "Amount", Type: basic("float64")},
+		}}, &Schema{Type: "object", Properties: map[string]*Schema{
+			"id":     {Type: "string"},
+			"Amount": {Type: "number", Format: "double"},
+		}}},
+		{"interface", &TypeDescriptor{Kind: "interface"}, &Schema{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := descriptorSchema(tt.d, "libreria-a", testTypes); !reflect.DeepEqual(got, tt.want) {
+				g, _ := json.Marshal(got)
+				w, _ := json.Marshal(tt.want)
+				t.Errorf("schema %s, want %s", g, w)
+			}
+		})
+	}
+}
+
+func TestRequestSchema(t *testing.T) {
+	account := &TypeDescriptor{Kind: "named", Name: "Account", Package: testLibPath}
+	tests := []struct {
+		name      string
+		inputs    []ParamMetadata
+		wantAnyOf bool // Params object or the struct itself
+	}{
+		{"single library struct", []ParamMetadata{{Name: "account", Type: "liba.Account", TypeInfo: account}}, true},
+		{"single foreign struct", []ParamMetadata{{Name: "account", Type: "bank.Account",
+			TypeInfo: &TypeDescriptor{Kind: "named", Name: "Account", Package: "github.com/other/bank"}}}, false},
+		{"struct and more", []ParamMetadata{
+			{Name: "account", Type: "liba.Account", TypeInfo: account},
+			{Name: "amount", Type: "float64", TypeInfo: &TypeDescriptor{Kind: "basic", Name: "float64"}},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := ServiceEntry{Namespace: "libreria-a", Method: "Open", Inputs: tt.inputs}
+			params := requestSchema(svc, testTypes).Properties["params"]
+			if tt.wantAnyOf {
+				if len(params.AnyOf) != 2 || params.AnyOf[1].Ref != "#/components/schemas/LibreriaAAccount" || params.OneOf != nil {
+					t.Fatalf("params %+v, want anyOf the params object and the struct", params)
+				}
+				params = params.AnyOf[0]
+			} else if params.AnyOf != nil {
+				t.Fatalf("params %+v, want the params object only", params)
+			}
+			if params.Type != "object" || params.Properties["account"] == nil {
+				t.Errorf("params object %+v", params)
+			}
+		})
+	}
+}
+
+func TestRequestSchemaAliases(t *testing.T) {
+	svc := ServiceEntry{Namespace: "libreria-a", Method: "GetUserBalance", Inputs: []ParamMetadata{
+		{Name: "user_id", Type: "string", TypeInfo: &TypeDescriptor{Kind: "basic", Name: "string"}},
+		{Name: "amount", Type: "float64", TypeInfo: &TypeDescriptor{Kind: "basic", Name: "float64"}},
+	}}
+	params := requestSchema(svc, testTypes).Properties["params"]
+	for _, key := range []string{"user_id", "userId", "UserId", "amount", "Amount"} {
+		if params.Properties[key] == nil {
+			t.Errorf("no property %s", key)
+		}
+	}
+	if len(params.AllOf) != 2 || len(params.AllOf[0].AnyOf) != 3 || len(params.AllOf[1].AnyOf) != 2 {
+		t.Errorf("want one required key per param: %+v", params.AllOf)
+	}
+	if params.Required != nil {
+		t.Errorf("required %v, want keys required through anyOf", params.Required)
+	}
+}
+
+func TestKeyAliases(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"user_id", []string{"userId", "UserId"}},
+		{"amount", []string{"Amount"}},
+		{"Amount", []string{"amount"}},
+		{"_", nil},
+	}
+	for _, tt := range tests {
+		if got := keyAliases(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("keyAliases(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAPIDocumentVersion(t *testing.T) {
+	cat := newCatalog()
+	cat.Services = []ServiceEntry{{Namespace: "libreria-a", Method: "Ping", Path: "/liba/Ping", Inputs: []ParamMetadata{}, Outputs: []ParamMetadata{}}}
+	doc := openAPIDocument(cat)
+	if doc.Info.Version != catalogHash(cat) {
+		t.Errorf("info.version %q, want the catalog hash %q", doc.Info.Version, catalogHash(cat))
+	}
+	cat.GeneratedAt = "2030-01-01T00:00:00Z"
+	if v := openAPIDocument(cat).Info.Version; v != doc.Info.Version {
+		t.Errorf("info.version changed with the build time: %s, was %s", v, doc.Info.Version)
+	}
+	if doc.Paths["/liba/Ping"].Post == nil {
+		t.Errorf("no operation for /liba/Ping")
+	}
+}
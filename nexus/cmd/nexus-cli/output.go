@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats are the values of --output. Without it commands print
+// their human-readable text.
+var outputFormats = []string{"json", "yaml", "table", "markdown"}
+
+// OutputOptions selects how a command prints its result.
+type OutputOptions struct {
+	Format string // json, yaml, table, markdown or empty for text
+	Quiet  bool   // Only namespace.method identifiers, one per line
+}
+
+// addOutputFlags registers --output and --quiet on cmd.
+func addOutputFlags(cmd *flag.FlagSet) *OutputOptions {
+	o := &OutputOptions{}
+	cmd.StringVar(&o.Format, "output", "", "Output format: "+strings.Join(outputFormats, ", ")+" (default text)")
+	cmd.BoolVar(&o.Quiet, "quiet", false, "Print only namespace.method identifiers")
+	return o
+}
+
+// Text reports whether the command prints its usual text.
+func (o OutputOptions) Text() bool {
+	return o.Format == "" && !o.Quiet
+}
+
+// apply validates the flags once parsed.
+func (o OutputOptions) apply() {
+	if o.Format != "" && !containsString(outputFormats, o.Format) {
+		fmt.Fprintf(o.Progress(), "Error: unknown output format %q, expected %s\n", o.Format, strings.Join(outputFormats, ", "))
+		os.Exit(1)
+	}
+}
+
+// Progress is where a command writes progress and error messages: stderr
+// when the result is meant for another program, so that stdout only holds
+// the result, and stdout otherwise.
+func (o OutputOptions) Progress() io.Writer {
+	if o.Quiet || o.Format == "json" || o.Format == "yaml" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Output is a command result in every format: Value is encoded as JSON or
+// YAML, Headers and Rows make the table and markdown views and IDs are
+// what --quiet prints.
+type Output struct {
+	Value   interface{}
+	Headers []string
+	Rows    [][]string
+	IDs     []string
+}
+
+// print writes out in the selected format.
+func (o OutputOptions) print(out Output) {
+	if err := o.write(os.Stdout, out); err != nil {
+		fmt.Fprintf(o.Progress(), "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (o OutputOptions) write(w io.Writer, out Output) error {
+	if o.Quiet {
+		for _, id := range out.IDs {
+			fmt.Fprintln(w, id)
+		}
+		return nil
+	}
+	switch o.Format {
+	case "json":
+		data, err := json.MarshalIndent(out.Value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		data, err := toYAML(out.Value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "markdown":
+		row := func(cells []string) {
+			escaped := make([]string, len(cells))
+			for i, c := range cells {
+				escaped[i] = strings.ReplaceAll(oneLine(c), "|", `\|`)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+		}
+		row(out.Headers)
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(out.Headers)))
+		for _, r := range out.Rows {
+			row(r)
+		}
+		return nil
+	default: // table
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(out.Headers, "\t")))
+		for _, r := range out.Rows {
+			cells := make([]string, len(r))
+			for i, c := range r {
+				cells[i] = oneLine(c)
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// toYAML encodes v with the same field names and order as its JSON form.
+func toYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML; decoding it into a node keeps the key order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	var blockStyle func(n *yaml.Node)
+	blockStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, c := range n.Content {
+			blockStyle(c)
+		}
+	}
+	blockStyle(&node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// oneLine joins the lines of a cell, such as a multi-line doc comment.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// paramList renders params as name type, name type.
+func paramList(params []ParamMetadata) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.Name + " " + p.Type
+	}
+	return strings.Join(parts, ", ")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestOutputWrite(t *testing.T) {
+	out := Output{
+		Value: struct {
+			Method string   `json:"method"`
+			Params []string `json:"params,omitempty"`
+			Count  int      `json:"count"`
+		}{"libreria-a.Transfer", []string{"from", "to"}, 2},
+		Headers: []string{"method", "description"},
+		Rows: [][]string{
+			{"libreria-a.Transfer", "Moves money\nbetween accounts"},
+			{"libreria-a.Or", "a | b"},
+		},
+		IDs: []string{"libreria-a.Transfer", "libreria-a.Or"},
+	}
+	tests := []struct {
+		name string
+		opts OutputOptions
+		want string
+	}{
+		{"json", OutputOptions{Format: "json"}, `{
+  "method": "libreria-a.Transfer",
+  "params": [
+    "from",
+    "to"
+  ],
+  "count": 2
+}
+`},
+		{"yaml", OutputOptions{Format: "yaml"}, `method: libreria-a.Transfer
+params:
+  - from
+  - to
+count: 2
+`},
+		{"markdown", OutputOptions{Format: "markdown"}, `| method | description |
+|---|---|
+| libreria-a.Transfer | Moves money between accounts |
+| libreria-a.Or | a \| b |
+`},
+		{"table", OutputOptions{Format: "table"}, `METHOD               DESCRIPTION
+libreria-a.Transfer  Moves money between accounts
+libreria-a.Or        a | b
+`},
+		{"quiet", OutputOptions{Format: "json", Quiet: true}, "libreria-a.Transfer\nlibreria-a.Or\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.opts.write(&buf, out); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputProgress(t *testing.T) {
+	tests := []struct {
+		opts     OutputOptions
+		progress *os.File
+		text     bool
+	}{
+		{OutputOptions{}, os.Stdout, true},
+		{OutputOptions{Format: "table"}, os.Stdout, false},
+		{OutputOptions{Format: "markdown"}, os.Stdout, false},
+		{OutputOptions{Format: "json"}, os.Stderr, false},
+		{OutputOptions{Format: "yaml"}, os.Stderr, false},
+		{OutputOptions{Quiet: true}, os.Stderr, false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Progress(); got != tt.progress {
+			t.Errorf("%+v: progress to %v, want %v", tt.opts, got.(*os.File).Name(), tt.progress.Name())
+		}
+		if got := tt.opts.Text(); got != tt.text {
+			t.Errorf("%+v: Text() = %v, want %v", tt.opts, got, tt.text)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ProtoPackage is the package of the generated nexus.proto. Each namespace
+// is a service of it: nexus.LibreriaA.
+const (
+	ProtoPackage = "nexus"
+	ProtoFile    = "nexus.proto"
+)
+
+// protoValue is the type of values without a protobuf equivalent (any,
+// inline structs, nested slices...): any JSON value, as with the HTTP routes.
+const protoValue = "google.protobuf.Value"
+
+// ProtoSchema is the protobuf form of a catalog: messages for the library
+// types and for the params and response of every routed service, and one
+// service per namespace.
+type ProtoSchema struct {
+	Messages []*ProtoMessage
+	Services []*ProtoService
+}
+
+type ProtoMessage struct {
+	Name    string
+	Comment string
+	Fields  []ProtoField
+}
+
+// ProtoField is a message field. Type is a scalar type or a message name;
+// map fields have a MapKey.
+type ProtoField struct {
+	Name     string
+	JSONName string // Key of the JSON form, when it differs from the protobuf default
+	Number   int32
+	Type     string
+	Repeated bool
+	Optional bool // Presence tracked, so that a missing param is reported as missing
+	MapKey   string
+	Comment  string
+}
+
+type ProtoService struct {
+	Name      string // LibreriaA
+	Namespace string
+	Methods   []ProtoMethod
+}
+
+type ProtoMethod struct {
+	Name    string // Transfer, BankServiceTransfer
+	Input   string
+	Output  string
+	Route   string // HTTP route with the same semantics
+	Service ServiceEntry
+}
+
+// protoType is the protobuf spelling of a catalog type.
+type protoType struct {
+	Name     string // Scalar type, message name or protoValue
+	Repeated bool
+	MapKey   string
+}
+
+func (t protoType) scalar() bool {
+	_, ok := protoScalarTypes[t.Name]
+	return ok && !t.Repeated && t.MapKey == ""
+}
+
+// goTypeComment documents the Go type of fields that protobuf cannot type.
+func (t protoType) goTypeComment(goType string) string {
+	if t.Name == protoValue {
+		return "Go type: " + goType
+	}
+	return ""
+}
+
+var protoScalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"uint32": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"float":  descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"double": descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"bytes":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// protoSchema builds the protobuf description of the routed services of
+// cat. Request fields are the params under their catalog keys and response
+// fields the keys of the HTTP response, so both transports share the param
+// resolution and coercion of the generated calls.
+func protoSchema(cat Catalog) ProtoSchema {
+	var schema ProtoSchema
+	types := map[string]TypeEntry{}
+	for _, t := range cat.Types {
+		types[t.Namespace+"."+t.Name] = t
+	}
+	names := map[string]bool{}
+	unique := func(name string) string {
+		base := name
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		names[name] = true
+		return name
+	}
+
+	for _, t := range cat.Types {
+		msg := &ProtoMessage{Name: unique(schemaName(t.Namespace, t.Name)), Comment: t.Description}
+		fieldNames := map[string]bool{}
+		var addFields func(t TypeEntry, seen map[string]bool)
+		addFields = func(t TypeEntry, seen map[string]bool) {
+			seen[t.Name] = true
+			for _, f := range t.Fields {
+				// encoding/json promotes the fields of embedded structs.
+				if embedded, ok := libraryType(embeddedType(f), t.Namespace, types); ok && f.Embedded {
+					if !seen[embedded.Name] {
+						addFields(embedded, seen)
+					}
+					continue
+				}
+				name := protoIdent(toSnakeCase(f.Name))
+				if fieldNames[name] {
+					continue
+				}
+				fieldNames[name] = true
+				ft := protoFieldType(f.TypeInfo, t.Namespace, types)
+				msg.Fields = append(msg.Fields, ProtoField{
+					Name:     name,
+					JSONName: f.Name,
+					Number:   int32(len(msg.Fields) + 1),
+					Type:     ft.Name,
+					Repeated: ft.Repeated,
+					MapKey:   ft.MapKey,
+					Comment:  f.Description,
+				})
+			}
+		}
+		addFields(t, map[string]bool{})
+		schema.Messages = append(schema.Messages, msg)
+	}
+
+	services := map[string]*ProtoService{}
+	for _, svc := range cat.Services {
+		if svc.Path == "" {
+			continue
+		}
+		ps, ok := services[svc.Namespace]
+		if !ok {
+			ps = &ProtoService{Name: toExportedName(svc.Namespace), Namespace: svc.Namespace}
+			services[svc.Namespace] = ps
+			schema.Services = append(schema.Services, ps)
+		}
+		method := toExportedName(svc.Receiver) + svc.Method
+		prefix := schemaName(svc.Namespace, method)
+
+		req := &ProtoMessage{Name: unique(prefix + "Request"), Comment: "Params of " + svc.Namespace + "." + svc.FullMethod() + "."}
+		for _, in := range svc.Inputs {
+			ft := protoFieldType(in.TypeInfo, svc.Namespace, types)
+			req.Fields = append(req.Fields, ProtoField{
+				Name:     protoIdent(in.Name),
+				Number:   int32(len(req.Fields) + 1),
+				Type:     ft.Name,
+				Repeated: ft.Repeated,
+				Optional: ft.scalar(),
+				MapKey:   ft.MapKey,
+				Comment:  ft.goTypeComment(in.Type),
+			})
+		}
+		resp := &ProtoMessage{Name: unique(prefix + "Response"), Comment: "Values returned by " + svc.Namespace + "." + svc.FullMethod() + "."}
+		outputs := svc.Outputs
+		if returnsError(outputs) {
+			outputs = outputs[:len(outputs)-1]
+		}
+		for _, out := range outputs {
+			key := toSnakeCase(out.Name)
+			if len(outputs) == 1 {
+				key = "result"
+			}
+			ft := protoFieldType(out.TypeInfo, svc.Namespace, types)
+			resp.Fields = append(resp.Fields, ProtoField{
+				Name:     protoIdent(key),
+				Number:   int32(len(resp.Fields) + 1),
+				Type:     ft.Name,
+				Repeated: ft.Repeated,
+				MapKey:   ft.MapKey,
+				Comment:  ft.goTypeComment(out.Type),
+			})
+		}
+		schema.Messages = append(schema.Messages, req, resp)
+		ps.Methods = append(ps.Methods, ProtoMethod{Name: method, Input: req.Name, Output: resp.Name, Route: svc.Path, Service: svc})
+	}
+	return schema
+}
+
+// embeddedType is the type of an embedded field without its pointer (Base
+// for *Base).
+func embeddedType(f FieldMetadata) *TypeDescriptor {
+	d := f.TypeInfo
+	if d != nil && d.Kind == "pointer" {
+		d = d.Elem
+	}
+	return d
+}
+
+// protoFieldType maps a catalog type to protobuf. Pointers are their
+// element; what protobuf cannot express, such as nested slices or maps of
+// slices, is a google.protobuf.Value.
+func protoFieldType(d *TypeDescriptor, namespace string, types map[string]TypeEntry) protoType {
+	value := protoType{Name: protoValue}
+	if d == nil {
+		return value
+	}
+	switch d.Kind {
+	case "basic":
+		if name := protoScalar(d.Name); name != "" {
+			return protoType{Name: name}
+		}
+	case "named":
+		if _, ok := libraryType(d, namespace, types); ok {
+			return protoType{Name: schemaName(namespace, d.Name)}
+		}
+		if d.Package+"."+d.Name == "time.Time" {
+			return protoType{Name: "string"} // RFC 3339
+		}
+	case "pointer":
+		return protoFieldType(d.Elem, namespace, types)
+	case "slice", "variadic", "array":
+		if d.Kind == "slice" && d.Elem != nil && d.Elem.Kind == "basic" && (d.Elem.Name == "byte" || d.Elem.Name == "uint8") {
+			return protoType{Name: "bytes"}
+		}
+		elem := protoFieldType(d.Elem, namespace, types)
+		if elem.Repeated || elem.MapKey != "" {
+			return value
+		}
+		elem.Repeated = true
+		return elem
+	case "map":
+		if d.Key == nil || d.Key.Kind != "basic" {
+			return value
+		}
+		key := protoScalar(d.Key.Name)
+		elem := protoFieldType(d.Elem, namespace, types)
+		if key == "" || key == "float" || key == "double" || key == "bytes" || elem.Repeated || elem.MapKey != "" {
+			return value
+		}
+		elem.MapKey = key
+		return elem
+	}
+	return value
+}
+
+func protoScalar(name string) string {
+	switch name {
+	case "string":
+		return "string"
+	case "bool":
+		return "bool"
+	case "int", "int64":
+		return "int64"
+	case "int8", "int16", "int32", "rune":
+		return "int32"
+	case "uint", "uint64", "uintptr":
+		return "uint64"
+	case "uint8", "uint16", "uint32", "byte":
+		return "uint32"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	}
+	return ""
+}
+
+// protoIdent turns a key into a valid protobuf identifier.
+func protoIdent(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '_'):
+			b.WriteRune(r)
+		case r < unicode.MaxASCII && unicode.IsDigit(r):
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// protoJSONName is the JSON name protoc derives from a field name.
+func protoJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// usesValue reports whether the schema needs google/protobuf/struct.proto.
+func (s ProtoSchema) usesValue() bool {
+	for _, m := range s.Messages {
+		for _, f := range m.Fields {
+			if f.Type == protoValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Source renders the schema as nexus.proto.
+func (s ProtoSchema) Source() string {
+	var b strings.Builder
+	comment := func(indent, text string) {
+		for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			if line == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "%s// %s\n", indent, line)
+		}
+	}
+
+	b.WriteString("// Code generated by nexus-cli. DO NOT EDIT.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n", ProtoPackage)
+	if s.usesValue() {
+		b.WriteString("\nimport \"google/protobuf/struct.proto\";\n")
+	}
+
+	for _, svc := range s.Services {
+		b.WriteString("\n")
+		comment("", "Functions of the "+svc.Namespace+" library, with the semantics of their HTTP routes.")
+		fmt.Fprintf(&b, "service %s {\n", svc.Name)
+		for i, m := range svc.Methods {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			comment("  ", m.Service.Description)
+			comment("  ", "HTTP: POST "+m.Route)
+			fmt.Fprintf(&b, "  rpc %s(%s) returns (%s);\n", m.Name, m.Input, m.Output)
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, m := range s.Messages {
+		b.WriteString("\n")
+		comment("", m.Comment)
+		fmt.Fprintf(&b, "message %s {\n", m.Name)
+		for _, f := range m.Fields {
+			comment("  ", f.Comment)
+			typ := f.Type
+			switch {
+			case f.MapKey != "":
+				typ = "map<" + f.MapKey + ", " + typ + ">"
+			case f.Repeated:
+				typ = "repeated " + typ
+			case f.Optional:
+				typ = "optional " + typ
+			}
+			fmt.Fprintf(&b, "  %s %s = %d", typ, f.Name, f.Number)
+			if f.JSONName != "" && f.JSONName != protoJSONName(f.Name) {
+				fmt.Fprintf(&b, " [json_name = %q]", f.JSONName)
+			}
+			b.WriteString(";\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// Descriptor returns the schema compiled as protoc would: the descriptor
+// of nexus.proto, checked against its imports.
+func (s ProtoSchema) Descriptor() (*descriptorpb.FileDescriptorProto, error) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(ProtoFile),
+		Package: proto.String(ProtoPackage),
+		Syntax:  proto.String("proto3"),
+	}
+	if s.usesValue() {
+		fd.Dependency = []string{structpb.File_google_protobuf_struct_proto.Path()}
+	}
+	typeName := func(name string) string {
+		if name == protoValue {
+			return "." + protoValue
+		}
+		return "." + ProtoPackage + "." + name
+	}
+	// setType fills the type of field, a scalar or a message.
+	setType := func(field *descriptorpb.FieldDescriptorProto, typ string) {
+		if t, ok := protoScalarTypes[typ]; ok {
+			field.Type = t.Enum()
+			return
+		}
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String(typeName(typ))
+	}
+
+	for _, m := range s.Messages {
+		msg := &descriptorpb.DescriptorProto{Name: proto.String(m.Name)}
+		for _, f := range m.Fields {
+			jsonName := f.JSONName
+			if jsonName == "" {
+				jsonName = protoJSONName(f.Name)
+			}
+			field := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(f.Name),
+				JsonName: proto.String(jsonName),
+				Number:   proto.Int32(f.Number),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			switch {
+			case f.MapKey != "":
+				// A map is a repeated entry message with key and value fields.
+				entry := &descriptorpb.DescriptorProto{
+					Name:    proto.String(toPascalCase(protoJSONName(f.Name)) + "Entry"),
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}
+				key := &descriptorpb.FieldDescriptorProto{Name: proto.String("key"), JsonName: proto.String("key"), Number: proto.Int32(1), Label: field.Label}
+				value := &descriptorpb.FieldDescriptorProto{Name: proto.String("value"), JsonName: proto.String("value"), Number: proto.Int32(2), Label: field.Label}
+				setType(key, f.MapKey)
+				setType(value, f.Type)
+				entry.Field = []*descriptorpb.FieldDescriptorProto{key, value}
+				msg.NestedType = append(msg.NestedType, entry)
+				field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+				field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				field.TypeName = proto.String(typeName(m.Name + "." + entry.GetName()))
+			case f.Repeated:
+				field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+				setType(field, f.Type)
+			default:
+				setType(field, f.Type)
+			}
+			if f.Optional {
+				// proto3 optional fields live in a synthetic oneof.
+				field.Proto3Optional = proto.Bool(true)
+				field.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+				msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + f.Name)})
+			}
+			msg.Field = append(msg.Field, field)
+		}
+		fd.MessageType = append(fd.MessageType, msg)
+	}
+
+	for _, svc := range s.Services {
+		sd := &descriptorpb.ServiceDescriptorProto{Name: proto.String(svc.Name)}
+		for _, m := range svc.Methods {
+			sd.Method = append(sd.Method, &descriptorpb.MethodDescriptorProto{
+				Name:       proto.String(m.Name),
+				InputType:  proto.String(typeName(m.Input)),
+				OutputType: proto.String(typeName(m.Output)),
+			})
+		}
+		fd.Service = append(fd.Service, sd)
+	}
+
+	if _, err := protodesc.NewFile(fd, protoregistry.GlobalFiles); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProtoFile, err)
+	}
+	return fd, nil
+}
+
+// DescriptorSet returns nexus.proto and its imports as a serialized
+// FileDescriptorSet, the output of protoc --include_imports
+// --descriptor_set_out. The generated gRPC server is built from it.
+func (s ProtoSchema) DescriptorSet() ([]byte, error) {
+	fd, err := s.Descriptor()
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if s.usesValue() {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(structpb.File_google_protobuf_struct_proto))
+	}
+	set.File = append(set.File, fd)
+	return proto.MarshalOptions{Deterministic: true}.Marshal(set)
+}
+
+// --- export proto ---
+
+// runExportProto prints nexus.proto for a catalog, or its rpcs in the
+// --output formats.
+func runExportProto(catalog Catalog, out OutputOptions) {
+	schema := protoSchema(catalog)
+	if _, err := schema.Descriptor(); err != nil {
+		fmt.Fprintf(out.Progress(), "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if out.Text() {
+		fmt.Print(schema.Source())
+		return
+	}
+
+	type protoRPC struct {
+		Service string `json:"service"` // nexus.LibreriaA
+		Method  string `json:"method"`
+		Input   string `json:"input"`
+		Output  string `json:"output"`
+		Route   string `json:"route"`
+	}
+	rpcs := []protoRPC{}
+	output := Output{Headers: []string{"RPC", "Input", "Output", "Route"}}
+	for _, svc := range schema.Services {
+		for _, m := range svc.Methods {
+			rpc := protoRPC{Service: ProtoPackage + "." + svc.Name, Method: m.Name, Input: m.Input, Output: m.Output, Route: m.Route}
+			rpcs = append(rpcs, rpc)
+			id := "/" + rpc.Service + "/" + rpc.Method
+			output.Rows = append(output.Rows, []string{id, m.Input, m.Output, "POST " + m.Route})
+			output.IDs = append(output.IDs, id)
+		}
+	}
+	output.Value = rpcs
+	out.print(output)
+}
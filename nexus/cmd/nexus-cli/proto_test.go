@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProtoFieldType(t *testing.T) {
+	basic := func(name string) *TypeDescriptor { return &TypeDescriptor{Kind: "basic", Name: name} }
+	account := &TypeDescriptor{Kind: "named", Name: "Account", Package: testLibPath}
+	tests := []struct {
+		name string
+		d    *TypeDescriptor
+		want protoType
+	}{
+		{"string", basic("string"), protoType{Name: "string"}},
+		{"int", basic("int"), protoType{Name: "int64"}},
+		{"byte", basic("byte"), protoType{Name: "uint32"}},
+		{"float64", basic("float64"), protoType{Name: "double"}},
+		{"complex", basic("complex128"), protoType{Name: protoValue}},
+		{"library struct", account, protoType{Name: "LibreriaAAccount"}},
+		{"foreign struct", &TypeDescriptor{Kind: "named", Name: "Account", Package: "github.com/other/bank"}, protoType{Name: protoValue}},
+		{"time", &TypeDescriptor{Kind: "named", Name: "Time", Package: "time"}, protoType{Name: "string"}},
+		{"pointer", &TypeDescriptor{Kind: "pointer", Elem: account}, protoType{Name: "LibreriaAAccount"}},
+		{"bytes", &TypeDescriptor{Kind: "slice", Elem: basic("byte")}, protoType{Name: "bytes"}},
+		{"slice", &TypeDescriptor{Kind: "slice", Elem: basic("string")}, protoType{Name: "string", Repeated: true}},
+		{"nested slice", &TypeDescriptor{Kind: "slice", Elem: &TypeDescriptor{Kind: "slice", Elem: basic("string")}}, protoType{Name: protoValue}},
+		{"map", &TypeDescriptor{Kind: "map", Key: basic("string"), Elem: basic("int")}, protoType{Name: "int64", MapKey: "string"}},
+		{"float key", &TypeDescriptor{Kind: "map", Key: basic("float64"), Elem: basic("int")}, protoType{Name: protoValue}},
+		{"map of slices", &TypeDescriptor{Kind: "map", Key: basic("string"), Elem: &TypeDescriptor{Kind: "slice", Elem: basic("int")}}, protoType{Name: protoValue}},
+		{"interface", &TypeDescriptor{Kind: "interface"}, protoType{Name: protoValue}},
+		{"nil", nil, protoType{Name: protoValue}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protoFieldType(tt.d, "libreria-a", testTypes); got != tt.want {
+				t.Errorf("protoFieldType = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoIdent(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"user_id", "user_id"},
+		{"2fa", "_2fa"},
+		{"año", "a_o"},
+		{"a-b", "a_b"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if got := protoIdent(tt.key); got != tt.want {
+			t.Errorf("protoIdent(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestProtoJSONName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"user_id", "userId"},
+		{"amount", "amount"},
+		{"_id", "Id"},
+	}
+	for _, tt := range tests {
+		if got := protoJSONName(tt.name); got != tt.want {
+			t.Errorf("protoJSONName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProtoSchema(t *testing.T) {
+	str := &TypeDescriptor{Kind: "basic", Name: "string"}
+	base := &TypeDescriptor{Kind: "named", Name: "Base", Package: testLibPath}
+	cat := Catalog{
+		Types: []TypeEntry{
+			{Namespace: "libreria-a", Package: testLibPath, Name: "Base", Fields: []FieldMetadata{
+				{Name: "id", Type: "string", TypeInfo: str},
+			}},
+			{Namespace: "libreria-a", Package: testLibPath, Name: "Account", Fields: []FieldMetadata{
+				{Name: "Base", Type: "*liba.Base", TypeInfo: &TypeDescriptor{Kind: "pointer", Elem: base}, Embedded: true},
+				{Name: "owner", Type: "string", TypeInfo: str},
+				{Name: "tags", Type: "[]interface{}", TypeInfo: &TypeDescriptor{Kind: "slice", Elem: &TypeDescriptor{Kind: "interface"}}},
+			}},
+		},
+		Services: []ServiceEntry{
+			{Namespace: "libreria-a", Method: "GetUserBalance", Path: "/liba/GetUserBalance",
+				Inputs:  []ParamMetadata{{Name: "user_id", Type: "string", TypeInfo: str}},
+				Outputs: []ParamMetadata{{Name: "balance", Type: "float64", TypeInfo: &TypeDescriptor{Kind: "basic", Name: "float64"}}, {Name: "err", Type: "error"}}},
+			{Namespace: "libreria-a", Receiver: "BankService", Method: "Open", Path: "/liba/BankService/Open",
+				Inputs:  []ParamMetadata{{Name: "account", Type: "liba.Account", TypeInfo: &TypeDescriptor{Kind: "named", Name: "Account", Package: testLibPath}}},
+				Outputs: []ParamMetadata{{Name: "id", Type: "string", TypeInfo: str}, {Name: "ok", Type: "bool", TypeInfo: &TypeDescriptor{Kind: "basic", Name: "bool"}}}},
+			{Namespace: "libreria-a", Method: "Map", Inputs: []ParamMetadata{}, Outputs: []ParamMetadata{}}, // Generic, no route
+		},
+	}
+	schema := protoSchema(cat)
+
+	messages := map[string]*ProtoMessage{}
+	for _, m := range schema.Messages {
+		messages[m.Name] = m
+	}
+	fields := func(name string) string {
+		m := messages[name]
+		if m == nil {
+			t.Fatalf("no message %s", name)
+		}
+		var list []string
+		for _, f := range m.Fields {
+			s := f.Name + ":" + f.Type
+			if f.Repeated {
+				s = "repeated " + s
+			}
+			if f.Optional {
+				s = "optional " + s
+			}
+			list = append(list, s)
+		}
+		return strings.Join(list, " ")
+	}
+	tests := []struct{ message, want string }{
+		{"LibreriaAAccount", "id:string owner:string repeated tags:" + protoValue},
+		{"LibreriaAGetUserBalanceRequest", "optional user_id:string"},
+		{"LibreriaAGetUserBalanceResponse", "result:double"},
+		{"LibreriaABankServiceOpenRequest", "account:LibreriaAAccount"},
+		{"LibreriaABankServiceOpenResponse", "id:string ok:bool"},
+	}
+	for _, tt := range tests {
+		if got := fields(tt.message); got != tt.want {
+			t.Errorf("%s fields %q, want %q", tt.message, got, tt.want)
+		}
+	}
+
+	if len(schema.Services) != 1 || len(schema.Services[0].Methods) != 2 {
+		t.Fatalf("services %+v, want LibreriaA with the 2 routed methods", schema.Services)
+	}
+	if m := schema.Services[0].Methods[1]; m.Name != "BankServiceOpen" || m.Route != "/liba/BankService/Open" {
+		t.Errorf("method %+v", m)
+	}
+
+	if _, err := schema.Descriptor(); err != nil {
+		t.Fatalf("descriptor does not compile: %v", err)
+	}
+	if src := schema.Source(); !strings.Contains(src, `import "google/protobuf/struct.proto";`) ||
+		!strings.Contains(src, "rpc BankServiceOpen(LibreriaABankServiceOpenRequest) returns (LibreriaABankServiceOpenResponse);") {
+		t.Errorf("nexus.proto:\n%s", src)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed registry.json
+var registryData []byte
+
+// projectRegistryFile is the project-level registry, read from the working directory.
+const projectRegistryFile = "nexus.yaml"
+
+// RegistryEntry is a library indexed by build.
+type RegistryEntry struct {
+	Path      string `json:"path" yaml:"path"`                               // Module import path
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"` // Catalog namespace alias
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`     // go get query: v1.2.3, v1.2, <v2.0.0, latest
+	Replace   string `json:"replace,omitempty" yaml:"replace,omitempty"`     // Local directory or module@version built instead
+	Disabled  bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Source    string `json:"-" yaml:"-"` // Layer the entry comes from: embedded, user or project
+	Dir       string `json:"-" yaml:"-"` // Absolute directory of a local Replace
+}
+
+// NamespaceName returns the catalog namespace of the library: the alias when
+// set, else the import path without the github.com/japablazatww/ prefix.
+func (e RegistryEntry) NamespaceName() string {
+	if e.Namespace != "" {
+		return e.Namespace
+	}
+	return strings.TrimPrefix(e.Path, "github.com/japablazatww/")
+}
+
+// VersionQuery returns the version passed to go get.
+func (e RegistryEntry) VersionQuery() string {
+	if e.Version != "" {
+		return e.Version
+	}
+	return "latest"
+}
+
+// RegistryFile is the content of the embedded, user and project registries.
+type RegistryFile struct {
+	Libraries []RegistryEntry `json:"libraries" yaml:"libraries"`
+}
+
+// registryScopes lists the editable registry layers, lowest priority first.
+var registryScopes = []string{"user", "project"}
+
+// placeholderVersion is required for modules replaced by a local directory,
+// as the go command does itself.
+const placeholderVersion = "v0.0.0-00010101000000-000000000000"
+
+// registryPath returns the file of an editable registry layer.
+func registryPath(scope string) (string, error) {
+	switch scope {
+	case "user":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".nexus", "registry.json"), nil
+	case "project":
+		return projectRegistryFile, nil
+	}
+	return "", fmt.Errorf("unknown registry scope %q (expected user or project)", scope)
+}
+
+// readRegistryFile reads a registry layer; a missing file is an empty registry.
+func readRegistryFile(path string) (RegistryFile, error) {
+	var reg RegistryFile
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return reg, nil
+	}
+	if err != nil {
+		return reg, err
+	}
+	if filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml" {
+		err = yaml.Unmarshal(data, &reg)
+	} else {
+		err = json.Unmarshal(data, &reg)
+	}
+	if err != nil {
+		return reg, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return reg, nil
+}
+
+func writeRegistryFile(path string, reg RegistryFile) error {
+	var data []byte
+	var err error
+	if filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml" {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(reg)
+		data = buf.Bytes()
+	} else {
+		data, err = json.MarshalIndent(reg, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// loadRegistry layers the user and project registries over the embedded
+// one. An entry replaces any entry with the same path from a lower layer;
+// disabled entries are kept so callers can report them. Local Replace
+// directories are relative to the registry file that declares them.
+func loadRegistry() ([]RegistryEntry, error) {
+	var embedded RegistryFile
+	if err := json.Unmarshal(registryData, &embedded); err != nil {
+		return nil, fmt.Errorf("error parsing internal registry: %w", err)
+	}
+	layers := []RegistryFile{embedded}
+	sources := []string{"embedded"}
+	dirs := []string{""}
+	for _, scope := range registryScopes {
+		path, err := registryPath(scope)
+		if err != nil {
+			return nil, err
+		}
+		reg, err := readRegistryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, reg)
+		sources = append(sources, scope)
+		dirs = append(dirs, dir)
+	}
+
+	var entries []RegistryEntry
+	index := map[string]int{}
+	for i, layer := range layers {
+		for _, e := range layer.Libraries {
+			if e.Path == "" {
+				return nil, fmt.Errorf("%s registry has an entry without path", sources[i])
+			}
+			e.Source = sources[i]
+			if e.Replace != "" && modfile.IsDirectoryPath(e.Replace) {
+				e.Dir = e.Replace
+				if !filepath.IsAbs(e.Dir) {
+					e.Dir = filepath.Join(dirs[i], e.Dir)
+				}
+			}
+			if j, ok := index[e.Path]; ok {
+				entries[j] = e
+				continue
+			}
+			index[e.Path] = len(entries)
+			entries = append(entries, e)
+		}
+	}
+
+	namespaces := map[string]string{}
+	for _, e := range entries {
+		if e.Disabled {
+			continue
+		}
+		ns := e.NamespaceName()
+		if other, ok := namespaces[ns]; ok {
+			return nil, fmt.Errorf("namespace %q is used by both %s and %s, set a namespace alias", ns, other, e.Path)
+		}
+		namespaces[ns] = e.Path
+	}
+	return entries, nil
+}
+
+// --- registry subcommand ---
+
+func runRegistry(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: nexus-cli registry <list|add|remove|enable|disable> [arguments]")
+		os.Exit(1)
+	}
+
+	cmd := flag.NewFlagSet("registry "+args[0], flag.ExitOnError)
+	scope := cmd.String("scope", "user", "Registry to edit: user (~/.nexus/registry.json) or project (./"+projectRegistryFile+")")
+	out := addOutputFlags(cmd)
+	parse := func() {
+		cmd.Parse(args[1:])
+		out.apply()
+	}
+	// Edits print the resulting registry when an output format is given.
+	done := func(format string, a ...interface{}) {
+		if out.Text() {
+			fmt.Printf(format, a...)
+		} else {
+			registryList(*out)
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		parse()
+		registryList(*out)
+	case "add":
+		namespace := cmd.String("namespace", "", "Catalog namespace alias (default derived from the path)")
+		version := cmd.String("version", "", "Version query for go get: v1.2.3, v1.2, <v2.0.0 (default latest)")
+		replace := cmd.String("replace", "", "Build from a local directory (./libreria-a) or another module (github.com/fork/libreria-a@v1.2.3)")
+		disabled := cmd.Bool("disabled", false, "Add the library disabled")
+		parse()
+		path := registryArg(cmd, out.Progress())
+		editRegistry(*scope, out.Progress(), func(reg *RegistryFile) error {
+			file, err := registryPath(*scope)
+			if err != nil {
+				return err
+			}
+			replacement, err := registryReplace(*replace, file)
+			if err != nil {
+				return err
+			}
+			entry := RegistryEntry{Path: path, Namespace: *namespace, Version: *version, Replace: replacement, Disabled: *disabled}
+			for i, e := range reg.Libraries {
+				if e.Path == path {
+					reg.Libraries[i] = entry
+					return nil
+				}
+			}
+			reg.Libraries = append(reg.Libraries, entry)
+			return nil
+		})
+		done("Added %s to the %s registry.\n", path, *scope)
+	case "remove":
+		parse()
+		path := registryArg(cmd, out.Progress())
+		editRegistry(*scope, out.Progress(), func(reg *RegistryFile) error {
+			for i, e := range reg.Libraries {
+				if e.Path == path {
+					reg.Libraries = append(reg.Libraries[:i], reg.Libraries[i+1:]...)
+					return nil
+				}
+			}
+			return fmt.Errorf("%s is not in the %s registry (use 'registry disable' to skip a built-in library)", path, *scope)
+		})
+		done("Removed %s from the %s registry.\n", path, *scope)
+	case "enable", "disable":
+		parse()
+		path := registryArg(cmd, out.Progress())
+		disable := args[0] == "disable"
+		entries, err := loadRegistry()
+		if err != nil {
+			fmt.Fprintf(out.Progress(), "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Start from the effective entry so its alias and version are kept.
+		entry := RegistryEntry{Path: path}
+		for _, e := range entries {
+			if e.Path == path {
+				entry = e
+			}
+		}
+		entry.Disabled = disable
+		editRegistry(*scope, out.Progress(), func(reg *RegistryFile) error {
+			for i, e := range reg.Libraries {
+				if e.Path == path {
+					reg.Libraries[i].Disabled = disable
+					return nil
+				}
+			}
+			reg.Libraries = append(reg.Libraries, entry)
+			return nil
+		})
+		done("%s %sd in the %s registry.\n", path, args[0], *scope)
+	default:
+		fmt.Println("Unknown registry command. Expected list, add, remove, enable or disable.")
+		os.Exit(1)
+	}
+}
+
+// registryReplace rewrites a --replace directory given relative to the
+// working directory for the registry file, which loadRegistry resolves
+// against its own directory: it is kept as is when the file is in the
+// working directory (the project registry) and made absolute otherwise.
+func registryReplace(replace, file string) (string, error) {
+	if replace == "" || !modfile.IsDirectoryPath(replace) || filepath.IsAbs(replace) {
+		return replace, nil
+	}
+	fileDir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return "", err
+	}
+	if cwd, err := os.Getwd(); err == nil && cwd == fileDir {
+		return replace, nil
+	}
+	return filepath.Abs(replace)
+}
+
+// registryArg returns the single library path argument of a registry command.
+func registryArg(cmd *flag.FlagSet, w io.Writer) string {
+	if cmd.NArg() != 1 {
+		fmt.Fprintf(w, "Usage: nexus-cli %s [flags] <import path>\n", cmd.Name())
+		os.Exit(1)
+	}
+	return cmd.Arg(0)
+}
+
+// editRegistry applies edit to the registry file of scope and saves it. The
+// previous file is restored when the layered registry becomes invalid.
+// Errors are written to w.
+func editRegistry(scope string, w io.Writer, edit func(reg *RegistryFile) error) {
+	path, err := registryPath(scope)
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	previous, readErr := os.ReadFile(path)
+	reg, err := readRegistryFile(path)
+	if err == nil {
+		err = edit(&reg)
+	}
+	if err == nil {
+		err = writeRegistryFile(path, reg)
+	}
+	if err == nil {
+		if _, err = loadRegistry(); err != nil {
+			if readErr == nil {
+				os.WriteFile(path, previous, 0644)
+			} else {
+				os.Remove(path)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// RegistryListing is an entry of registry list with --output.
+type RegistryListing struct {
+	Path      string `json:"path"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+	Replace   string `json:"replace,omitempty"`
+	Enabled   bool   `json:"enabled"`
+	Source    string `json:"source"`
+}
+
+func registryList(out OutputOptions) {
+	entries, err := loadRegistry()
+	if err != nil {
+		fmt.Fprintf(out.Progress(), "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !out.Text() {
+		listing := []RegistryListing{}
+		output := Output{Headers: []string{"Path", "Namespace", "Version", "Replace", "Enabled", "Source"}}
+		for _, e := range entries {
+			l := RegistryListing{Path: e.Path, Namespace: e.NamespaceName(), Version: e.VersionQuery(), Replace: e.Replace, Enabled: !e.Disabled, Source: e.Source}
+			listing = append(listing, l)
+			output.Rows = append(output.Rows, []string{l.Path, l.Namespace, l.Version, l.Replace, strconv.FormatBool(l.Enabled), l.Source})
+			output.IDs = append(output.IDs, l.Namespace)
+		}
+		output.Value = listing
+		out.print(output)
+		return
+	}
+	fmt.Println("Registered Libraries:")
+	for _, e := range entries {
+		status := "enabled"
+		if e.Disabled {
+			status = "disabled"
+		}
+		fmt.Printf("- %s (%s, %s)\n", e.Path, status, e.Source)
+		fmt.Printf("  Namespace: %s\n  Version: %s\n", e.NamespaceName(), e.VersionQuery())
+		if e.Replace != "" {
+			fmt.Printf("  Replace: %s\n", e.Replace)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+// inRegistryDirs points the user registry at a temporary home and runs the
+// test from a temporary project directory, returning both.
+func inRegistryDirs(t *testing.T) (home, project string) {
+	t.Helper()
+	home, project = t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return home, project
+}
+
+func TestLoadRegistry(t *testing.T) {
+	home, project := inRegistryDirs(t)
+	user := RegistryFile{Libraries: []RegistryEntry{
+		{Path: "github.com/japablazatww/libreria-a", Version: "v1.2"},
+		{Path: "github.com/japablazatww/libreria-b", Namespace: "pagos"},
+	}}
+	if err := writeRegistryFile(filepath.Join(home, ".nexus", "registry.json"), user); err != nil {
+		t.Fatal(err)
+	}
+	projectRegistry := `libraries:
+  - path: github.com/japablazatww/libreria-b
+    disabled: true
+  - path: github.com/japablazatww/libreria-c
+    replace: ../libreria-c
+`
+	if err := os.WriteFile(filepath.Join(project, projectRegistryFile), []byte(projectRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, strings.Join([]string{e.Path, e.Source, e.NamespaceName(), e.VersionQuery(), e.Dir}, " "))
+		if e.Disabled {
+			got[len(got)-1] += " disabled"
+		}
+	}
+	// t.TempDir may be behind a symlink: compare with the absolute path
+	// loadRegistry resolves.
+	abs, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"github.com/japablazatww/libreria-a user libreria-a v1.2 ",
+		"github.com/japablazatww/libreria-b project libreria-b latest  disabled",
+		"github.com/japablazatww/libreria-c project libreria-c latest " + filepath.Join(filepath.Dir(abs), "libreria-c"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("registry\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLoadRegistryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		wantErr string
+	}{
+		{"duplicate namespace", `libraries:
+  - path: github.com/japablazatww/libreria-b
+    namespace: libreria-a
+`, `namespace "libreria-a" is used by both github.com/japablazatww/libreria-a and github.com/japablazatww/libreria-b`},
+		{"duplicate namespace disabled", `libraries:
+  - path: github.com/japablazatww/libreria-b
+    namespace: libreria-a
+    disabled: true
+`, ""},
+		{"missing path", `libraries:
+  - namespace: pagos
+`, "project registry has an entry without path"},
+		{"invalid", `libraries: {`, "error parsing nexus.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, project := inRegistryDirs(t)
+			if err := os.WriteFile(filepath.Join(project, projectRegistryFile), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadRegistry()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistryFileRoundTrip(t *testing.T) {
+	reg := RegistryFile{Libraries: []RegistryEntry{
+		{Path: "github.com/japablazatww/libreria-a", Namespace: "bank", Version: "v1.2"},
+		{Path: "github.com/japablazatww/libreria-b", Replace: "../libreria-b", Disabled: true},
+	}}
+	for _, name := range []string{"registry.json", "nexus.yaml", "nexus.yml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sub", name)
+			if err := writeRegistryFile(path, reg); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readRegistryFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, reg) {
+				t.Errorf("read %+v, wrote %+v", got, reg)
+			}
+		})
+	}
+
+	got, err := readRegistryFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(got.Libraries) != 0 {
+		t.Errorf("missing registry: %+v, %v; want an empty registry", got, err)
+	}
+}
+
+func TestRegistryPath(t *testing.T) {
+	home, _ := inRegistryDirs(t)
+	tests := []struct {
+		scope   string
+		want    string
+		wantErr bool
+	}{
+		{"user", filepath.Join(home, ".nexus", "registry.json"), false},
+		{"project", projectRegistryFile, false},
+		{"system", "", true},
+	}
+	for _, tt := range tests {
+		got, err := registryPath(tt.scope)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("registryPath(%q) = %q, %v; want %q, error %v", tt.scope, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRegistryAddReplace(t *testing.T) {
+	home, project := inRegistryDirs(t)
+	tests := []struct {
+		scope   string
+		replace string
+		stored  string // Replace written to the registry file
+	}{
+		{"user", "./libreria-a", filepath.Join(project, "libreria-a")},
+		{"user", "../libreria-a", filepath.Join(filepath.Dir(project), "libreria-a")},
+		{"user", "/src/libreria-a", "/src/libreria-a"},
+		{"user", "github.com/fork/libreria-a@v1.2.3", "github.com/fork/libreria-a@v1.2.3"},
+		{"project", "./libreria-a", "./libreria-a"},
+		{"project", "../libreria-a", "../libreria-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope+" "+tt.replace, func(t *testing.T) {
+			runRegistry([]string{"add", "--scope", tt.scope, "--replace", tt.replace, "github.com/japablazatww/libreria-a"})
+			file := filepath.Join(home, ".nexus", "registry.json")
+			if tt.scope == "project" {
+				file = filepath.Join(project, projectRegistryFile)
+			}
+			reg, err := readRegistryFile(file)
+			if err != nil || len(reg.Libraries) != 1 || reg.Libraries[0].Replace != tt.stored {
+				t.Fatalf("%s registry %+v, %v; want replace %s", tt.scope, reg, err, tt.stored)
+			}
+			entries, err := loadRegistry()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Local directories resolve from the working directory the
+			// replace was given in.
+			if modfile.IsDirectoryPath(tt.replace) {
+				want, _ := filepath.Abs(tt.replace)
+				for _, e := range entries {
+					if e.Path == "github.com/japablazatww/libreria-a" && e.Dir != want {
+						t.Errorf("resolved to %s, want %s", e.Dir, want)
+					}
+				}
+			}
+		})
+	}
+}
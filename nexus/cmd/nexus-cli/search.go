@@ -0,0 +1,52 @@
+package main
+
+import "github.com/japablazatww/centralnexus/nexus/search"
+
+// SearchQuery holds the search filters, ranked by package search like the
+// /_nexus/search endpoint of the generated server.
+type SearchQuery = search.Query
+
+// SearchResponse is the JSON and YAML output of search.
+type SearchResponse struct {
+	Query   SearchQuery    `json:"query"`
+	Count   int            `json:"count"`
+	Results []SearchResult `json:"results"`
+}
+
+// searchCatalog returns the services matching every filter of q, best
+// ranked first (all services, by name, for an empty query). The score of a
+// service is the sum of its filter scores.
+func searchCatalog(catalog Catalog, q SearchQuery) []SearchResult {
+	services := make([]search.Service, len(catalog.Services))
+	for i, svc := range catalog.Services {
+		services[i] = search.Service{
+			Namespace:   svc.Namespace,
+			Receiver:    svc.Receiver,
+			Method:      svc.Method,
+			Description: svc.Description,
+			Inputs:      searchParams(svc.Inputs),
+			Outputs:     searchParams(svc.Outputs),
+		}
+	}
+	var results []SearchResult
+	for _, m := range search.Rank(services, q) {
+		svc := catalog.Services[m.Index]
+		results = append(results, SearchResult{
+			Namespace:    svc.Namespace,
+			Method:       svc.FullMethod(),
+			MatchedParam: m.MatchedParam,
+			ParamType:    m.ParamType,
+			Score:        m.Score,
+			Service:      svc,
+		})
+	}
+	return results
+}
+
+func searchParams(params []ParamMetadata) []search.Param {
+	out := make([]search.Param, len(params))
+	for i, p := range params {
+		out[i] = search.Param{Name: p.Name, Type: p.Type}
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package generated
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useForTest adds interceptors for the duration of the test.
+func useForTest(t *testing.T, pattern string, interceptor ...Interceptor) {
+	t.Helper()
+	middlewareMu.RLock()
+	saved := interceptors
+	middlewareMu.RUnlock()
+	Use(pattern, interceptor...)
+	t.Cleanup(func() {
+		middlewareMu.Lock()
+		defer middlewareMu.Unlock()
+		interceptors = saved
+	})
+}
+
+func batchCall(method string, params string) string {
+	return fmt.Sprintf(`{"namespace": "libreria-a", "method": %q, "params": %s}`, method, params)
+}
+
+func TestBatch(t *testing.T) {
+	ok := batchCall("GetSystemStatus", `{"code": "ADMIN123"}`)
+	fails := batchCall("GetUserBalance", `{}`)
+	unknown := batchCall("Nope", `{}`)
+	tests := []struct {
+		name  string
+		body  string
+		codes []string // Status and error code of every result
+	}{
+		{"sequential", `{"calls": [` + ok + `, ` + ok + `]}`, []string{"200", "200"}},
+		{"sequential skips after a failure", `{"calls": [` + ok + `, ` + fails + `, ` + ok + `, ` + ok + `]}`,
+			[]string{"200", "400 missing_param", "424 skipped", "424 skipped"}},
+		{"unknown service", `{"calls": [` + unknown + `, ` + ok + `]}`, []string{"404 service_not_found", "424 skipped"}},
+		{"parallel runs every call", `{"parallel": true, "calls": [` + fails + `, ` + ok + `, ` + unknown + `]}`,
+			[]string{"400 missing_param", "200", "404 service_not_found"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, "/_nexus/batch", tt.body)
+			var resp BatchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+			}
+			var codes []string
+			for _, r := range resp.Results {
+				code := fmt.Sprint(r.Status)
+				if r.Error != nil {
+					code += " " + r.Error.Code
+				}
+				codes = append(codes, code)
+			}
+			if strings.Join(codes, ", ") != strings.Join(tt.codes, ", ") {
+				t.Errorf("results %q, want %q", codes, tt.codes)
+			}
+		})
+	}
+
+	w := post(t, "/_nexus/batch", `{"calls": [`+ok+`, `+fails+`, `+ok+`]}`)
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if got := resp.Results[2].Error; got == nil || got.Message != "not run, call 1 failed" || got.Method != "GetSystemStatus" {
+		t.Errorf("skipped result %+v", got)
+	}
+	if got := string(resp.Results[0].Result); got != `{"result":"OPERATIONAL"}` {
+		t.Errorf("result %s", got)
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	ConfigureBatch(BatchLimits{MaxCalls: 3, MaxConcurrency: 2})
+	t.Cleanup(func() { ConfigureBatch(BatchLimits{}) })
+
+	ok := batchCall("GetSystemStatus", `{"code": "ADMIN123"}`)
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{"no calls", `{"calls": []}`, http.StatusBadRequest, "batch has no calls"},
+		{"too many calls", `{"calls": [` + strings.Repeat(ok+",", 3) + ok + `]}`, http.StatusRequestEntityTooLarge, "batch has 4 calls, at most 3 allowed"},
+		{"invalid body", `{"calls": 1}`, http.StatusBadRequest, "invalid request body: json: cannot unmarshal number into Go struct field BatchRequest.calls of type []generated.BatchCall"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, "/_nexus/batch", tt.body)
+			var body struct{ Error APIError }
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != tt.status || body.Error.Code != CodeInvalidRequest || body.Error.Message != tt.message {
+				t.Errorf("%d %+v, want %d %s", w.Code, body.Error, tt.status, tt.message)
+			}
+		})
+	}
+
+	// A parallel batch asking for more concurrency runs at most
+	// MaxConcurrency calls at a time.
+	var running, peak atomic.Int32
+	useForTest(t, "libreria-a.GetSystemStatus", func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return next(ctx, info)
+	})
+	w := post(t, "/_nexus/batch", `{"parallel": true, "concurrency": 50, "calls": [`+ok+`, `+ok+`, `+ok+`]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%d %s", w.Code, w.Body)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("%d calls ran at a time, want 2", got)
+	}
+}
@@ -0,0 +1,160 @@
+package generated
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/japablazatww/centralnexus/nexus/search"
+)
+
+func get(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	RegisterHandlers(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestCatalogSearch(t *testing.T) {
+	tests := []struct {
+		query   string
+		first   string // namespace.method of the best result
+		matched string // Its matched param and param type
+		count   int
+	}{
+		{"method=GetSystemStatus", "libreria-a.GetSystemStatus", "", 1},
+		{"method=getsystemstatu", "libreria-a.GetSystemStatus", "", 1},
+		{"param=code", "libreria-a.GetSystemStatus", "code Input", 1},
+		{"param=amount&out", "", "", 0},
+		{"type=float64&out", "libreria-a.GetUserBalance", "result_0 Output", 1},
+		{"text=transfer", "libreria-a.Transfer", "", 1},
+		{"method=nothing", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := get(t, "/_nexus/search?"+tt.query)
+			var resp struct {
+				Count   int `json:"count"`
+				Results []struct {
+					Namespace    string          `json:"namespace"`
+					Method       string          `json:"method"`
+					MatchedParam string          `json:"matched_param"`
+					ParamType    string          `json:"param_type"`
+					Service      json.RawMessage `json:"service"`
+				} `json:"results"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+			}
+			if resp.Count != tt.count || len(resp.Results) != tt.count {
+				t.Fatalf("%d results, want %d: %s", len(resp.Results), tt.count, w.Body)
+			}
+			if tt.count == 0 {
+				return
+			}
+			r := resp.Results[0]
+			matched := r.MatchedParam
+			if r.ParamType != "" {
+				matched += " " + r.ParamType
+			}
+			if r.Namespace+"."+r.Method != tt.first || matched != tt.matched || len(r.Service) == 0 {
+				t.Errorf("first result %+v, want %s matching %q with its catalog entry", r, tt.first, tt.matched)
+			}
+		})
+	}
+}
+
+func TestCatalogEndpoints(t *testing.T) {
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/_nexus/catalog", http.StatusOK},
+		{"GET", "/_nexus/services/libreria-a/Transfer", http.StatusOK},
+		{"GET", "/_nexus/services/libreria-a/Nope", http.StatusNotFound},
+		{"POST", "/_nexus/search", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		mux := http.NewServeMux()
+		RegisterHandlers(mux)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+	}
+}
+
+func TestCatalogInvalid(t *testing.T) {
+	saved := CatalogJSON
+	reset := func() {
+		catalogOnce, catalogServices, catalogErr = sync.Once{}, nil, nil
+	}
+	reset()
+	CatalogJSON = []byte(`{"services": {}}`)
+	t.Cleanup(func() {
+		CatalogJSON = saved
+		reset()
+	})
+
+	w := get(t, "/_nexus/search?method=Transfer")
+	var body struct{ Error APIError }
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusInternalServerError || body.Error.Code != CodeCatalogError {
+		t.Errorf("%d %s, want 500 %s", w.Code, w.Body, CodeCatalogError)
+	}
+}
+
+// The search endpoint ranks with a copy of package search: both must agree.
+func TestSearchRankMatchesPackageSearch(t *testing.T) {
+	services, err := loadCatalogServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied []searchService
+	var original []search.Service
+	for _, svc := range services {
+		copied = append(copied, svc.searchService)
+		data, _ := json.Marshal(svc.searchService)
+		var s search.Service
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+		original = append(original, s)
+	}
+	queries := []search.Query{
+		{},
+		{Method: "transfer"},
+		{Method: "liba.getsystemstatu"},
+		{Param: "acount", Inputs: true},
+		{Param: "amount", Outputs: true},
+		{Type: "float64"},
+		{Type: "float32"},
+		{Type: "string", Param: "code"},
+		{Text: "balance user"},
+	}
+	for _, q := range queries {
+		want := search.Rank(original, q)
+		got := searchRank(copied, searchQuery(q))
+		if len(got) != len(want) {
+			t.Errorf("%+v: %d matches, package search has %d", q, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if w := want[i]; got[i] != (searchMatch{w.Index, w.MatchedParam, w.ParamType, w.Score}) {
+				t.Errorf("%+v: match %d is %+v, package search has %+v", q, i, got[i], w)
+			}
+		}
+	}
+	for _, tt := range []struct{ query, typ string }{
+		{"float64", "[]float64"}, {"int", "map[string]int"}, {"int", "interface{}"}, {"float64", "float32"},
+	} {
+		if got, want := typeScore(tt.query, tt.typ), search.TypeScore(tt.query, tt.typ); got != want {
+			t.Errorf("typeScore(%q, %q) = %d, package search has %d", tt.query, tt.typ, got, want)
+		}
+	}
+}
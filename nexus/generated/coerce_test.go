@@ -0,0 +1,139 @@
+package generated
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// post sends body to path on a mux with the generated handlers.
+func post(t *testing.T, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	RegisterHandlers(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	return w
+}
+
+type coerceBase struct {
+	ID string `json:"id"`
+}
+
+type coerceAccount struct {
+	coerceBase
+	Owner   string `json:"owner"`
+	Balance float64
+	Tags    []string `json:"tags,omitempty"`
+	Secret  string   `json:"-"`
+}
+
+func TestCoerceParam(t *testing.T) {
+	opened := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		json    string
+		dst     interface{} // Pointer to the target type
+		want    interface{}
+		wantErr string // ParamError message
+	}{
+		{"int", `42`, new(int), 42, ""},
+		{"int from string", `"42"`, new(int), 42, ""},
+		{"int from integral float", `1e3`, new(int), 1000, ""},
+		{"int fraction", `1.5`, new(int), nil, "invalid param p: expected int, got number 1.5 (not an integer in range)"},
+		{"int8 overflow", `128`, new(int8), nil, "invalid param p: expected int8, got number 128 (overflow)"},
+		{"int64 full precision", `9007199254740993`, new(int64), int64(9007199254740993), ""},
+		{"uint negative", `-1`, new(uint), nil, "invalid param p: expected uint, got number -1 (not an unsigned integer in range)"},
+		{"uint8 overflow", `256`, new(uint8), nil, "invalid param p: expected uint8, got number 256 (overflow)"},
+		{"float32 overflow", `1e39`, new(float32), nil, "invalid param p: expected float32, got number 1e39 (overflow)"},
+		{"float from bool", `true`, new(float64), nil, "invalid param p: expected float64, got bool true"},
+		{"bool from string", `"true"`, new(bool), true, ""},
+		{"string from number", `1`, new(string), nil, "invalid param p: expected string, got number 1"},
+		{"null string", `null`, new(string), nil, "invalid param p: expected string, got null"},
+		{"null slice", `null`, new([]int), []int(nil), ""},
+		{"duration text", `"1m30s"`, new(time.Duration), 90 * time.Second, ""},
+		{"duration nanoseconds", `1500`, new(time.Duration), time.Duration(1500), ""},
+		{"invalid duration", `"soon"`, new(time.Duration), nil, `invalid param p: expected time.Duration, got "soon" (time: invalid duration "soon")`},
+		{"time", `"2025-01-02T03:04:05Z"`, new(time.Time), opened, ""},
+		{"invalid time", `"yesterday"`, new(time.Time), nil, `invalid param p: expected time.Time, got "yesterday"`},
+		{"bytes base64", `"aGk="`, new([]byte), []byte("hi"), ""},
+		{"invalid base64", `"%%%"`, new([]byte), nil, `invalid param p: expected []uint8, got "%%%" (invalid base64)`},
+		{"bytes as array", `[104, 105]`, new([]byte), []byte("hi"), ""},
+		{"slice element", `[1, "x"]`, new([]int), nil, `invalid param p[1]: expected int, got "x"`},
+		{"array length", `[1, 2]`, new([3]int), nil, "invalid param p: expected [3]int, got array (want 3 elements, got 2)"},
+		{"map", `{"a": 1}`, new(map[string]int), map[string]int{"a": 1}, ""},
+		{"map int keys", `{"7": "x"}`, new(map[int]string), map[int]string{7: "x"}, ""},
+		{"map value", `{"a": "x"}`, new(map[string]int), nil, `invalid param p.a: expected int, got "x"`},
+		{"pointer", `5`, new(*int), func() *int { n := 5; return &n }(), ""},
+		{"interface", `{"n": 1}`, new(interface{}), map[string]interface{}{"n": 1.0}, ""},
+		{"struct", `{"id": "A1", "OWNER": "ana", "balance": "10.5", "tags": ["vip"]}`, new(coerceAccount),
+			coerceAccount{coerceBase: coerceBase{ID: "A1"}, Owner: "ana", Balance: 10.5, Tags: []string{"vip"}}, ""},
+		{"unknown field", `{"owner": "ana", "nickname": "a"}`, new(coerceAccount), nil,
+			"invalid param p.nickname: expected generated.coerceAccount, got \"a\" (unknown field)"},
+		{"skipped field is unknown", `{"secret": "x"}`, new(coerceAccount), nil,
+			"invalid param p.secret: expected generated.coerceAccount, got \"x\" (unknown field)"},
+		{"struct field", `{"balance": "lots"}`, new(coerceAccount), nil, `invalid param p.Balance: expected float64, got "lots"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tt.json))
+			dec.UseNumber()
+			var val interface{}
+			if err := dec.Decode(&val); err != nil {
+				t.Fatal(err)
+			}
+			err := coerceParam("p", val, tt.dst)
+			if tt.wantErr != "" {
+				if _, ok := err.(*ParamError); !ok || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := reflect.ValueOf(tt.dst).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coerced %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeCallParamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   APIError
+	}{
+		{"missing", `{"params": {"sourceAccount": "A", "destAccount": "B", "currency": "EUR"}}`, http.StatusBadRequest,
+			APIError{Code: CodeMissingParam, Parameter: "amount", Message: "param amount not found in request params"}},
+		{"invalid", `{"params": {"sourceAccount": "A", "destAccount": "B", "amount": "lots", "currency": "EUR"}}`, http.StatusBadRequest,
+			APIError{Code: CodeInvalidParam, Parameter: "amount", Message: `invalid param amount: expected float64, got "lots"`,
+				Details: map[string]interface{}{"expected": "float64", "got": `"lots"`}}},
+		{"invalid body", `{"params": [`, http.StatusBadRequest,
+			APIError{Code: CodeInvalidRequest, Message: "invalid request body: unexpected EOF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, "/liba/Transfer", tt.body)
+			var body struct{ Error APIError }
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+			}
+			tt.want.Library, tt.want.Method = "libreria-a", "Transfer"
+			if w.Code != tt.status || !reflect.DeepEqual(body.Error, tt.want) {
+				t.Errorf("%d %+v\nwant %d %+v", w.Code, body.Error, tt.status, tt.want)
+			}
+		})
+	}
+
+	w := post(t, "/liba/Transfer", `{"params": {"source_account": "A", "DESTACCOUNT": "B", "amount": "12.5", "currency": "EUR"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"result":"TX-123456789"`) {
+		t.Errorf("Transfer with loose param names: %d %s", w.Code, w.Body)
+	}
+}
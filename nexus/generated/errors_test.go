@@ -0,0 +1,83 @@
+package generated
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterHandlers(mux)
+	mux.HandleFunc("/liba/Plain", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gateway down", http.StatusBadGateway)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func() error
+		status   int
+		code     string
+		sentinel error
+		param    string
+	}{
+		{"missing param", func() error {
+			_, err := client.LibreriaA.GetSystemStatusGeneric(ctx, GenericRequest{Params: map[string]interface{}{}})
+			return err
+		}, http.StatusBadRequest, CodeMissingParam, ErrMissingParam, "code"},
+		{"invalid param", func() error {
+			_, err := client.LibreriaA.TransferGeneric(ctx, GenericRequest{Params: map[string]interface{}{
+				"sourceAccount": "A", "destAccount": "B", "amount": true, "currency": "EUR"}})
+			return err
+		}, http.StatusBadRequest, CodeInvalidParam, ErrInvalidParam, "amount"},
+		{"library error", func() error {
+			_, err := client.LibreriaA.GetSystemStatus(ctx, LibreriaAGetSystemStatusRequest{Code: "guess"})
+			return err
+		}, http.StatusInternalServerError, CodeLibraryError, ErrLibraryError, ""},
+		{"response without envelope", func() error {
+			return client.call(ctx, "/liba/Plain", nil, nil)
+		}, http.StatusBadGateway, CodeHTTPError, ErrHTTPError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error %v (%T), want an *APIError", err, err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.code || apiErr.Parameter != tt.param {
+				t.Errorf("error %+v, want status %d, code %s, parameter %q", apiErr, tt.status, tt.code, tt.param)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *APIError
+		target error
+		want   bool
+	}{
+		{"own sentinel", &APIError{Code: CodeCircuitOpen}, ErrCircuitOpen, true},
+		{"other sentinel", &APIError{Code: CodeCircuitOpen}, ErrLibraryError, false},
+		{"library code", &APIError{Code: "account_not_found", StatusCode: http.StatusNotFound}, ErrLibraryError, true},
+		{"library code status", &APIError{Code: "account_not_found", StatusCode: http.StatusNotFound}, ErrNotFound, true},
+		{"library code other status", &APIError{Code: "account_not_found", StatusCode: http.StatusNotFound}, ErrConflict, false},
+		{"nil target", &APIError{Code: "account_not_found"}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("%s: errors.Is = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package generated
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestServeGRPCUnsetFields(t *testing.T) {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		message  = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	code := field("code", 1, str, optional)
+	code.Proto3Optional, code.OneofIndex = proto.Bool(true), proto.Int32(0)
+	account := field("account", 3, message, optional)
+	account.TypeName = proto.String(".test.Account")
+	limits := field("limits", 4, message, repeated)
+	limits.TypeName = proto.String(".test.Request.LimitsEntry")
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Account"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, str, optional)}},
+			{
+				Name:  proto.String("Request"),
+				Field: []*descriptorpb.FieldDescriptorProto{code, field("tags", 2, str, repeated), account, limits},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:    proto.String("LimitsEntry"),
+					Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, str, optional), field("value", 2, str, optional)},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("_code")}},
+			},
+			{Name: proto.String("Response")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Test"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name: proto.String("Call"), InputType: proto.String(".test.Request"), OutputType: proto.String(".test.Response"),
+			}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := fd.Services().Get(0).Methods().Get(0)
+
+	var got map[string]interface{}
+	call := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		got = params
+		return map[string]interface{}{}, nil
+	}
+	if _, err := serveGRPC(context.Background(), md, call, dynamicpb.NewMessage(md.Input())); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"tags":    []interface{}{},
+		"account": map[string]interface{}{},
+		"limits":  map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("params %#v, want %#v: empty values, and no unset optional code", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package generated
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useHTTPForTest adds middleware for the duration of the test.
+func useHTTPForTest(t *testing.T, pattern string, middleware ...Middleware) {
+	t.Helper()
+	middlewareMu.RLock()
+	saved := middlewares
+	middlewareMu.RUnlock()
+	UseHTTP(pattern, middleware...)
+	t.Cleanup(func() {
+		middlewareMu.Lock()
+		defer middlewareMu.Unlock()
+		middlewares = saved
+		middlewareVersion.Add(1)
+	})
+}
+
+func TestInterceptors(t *testing.T) {
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+			order = append(order, name+" "+info.Namespace+"."+info.Method)
+			return next(ctx, info)
+		}
+	}
+	useForTest(t, "*", trace("all"))
+	useForTest(t, "libreria-a", trace("namespace"))
+	useForTest(t, "libreria-a.GetSystemStatus", trace("method"), func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+		info.Params = map[string]interface{}{"code": "ADMIN123"} // Replaces the params
+		return next(ctx, info)
+	})
+	useForTest(t, "libreria-b", trace("other library"))
+
+	for _, transport := range []struct{ path, body string }{
+		{"/liba/GetSystemStatus", `{"params": {"code": "guess"}}`},
+		{"/rpc", `{"jsonrpc": "2.0", "id": 1, "method": "liba.GetSystemStatus", "params": {"code": "guess"}}`},
+		{"/_nexus/batch", `{"calls": [{"namespace": "libreria-a", "method": "GetSystemStatus", "params": {"code": "guess"}}]}`},
+	} {
+		order = nil
+		w := post(t, transport.path, transport.body)
+		if !strings.Contains(w.Body.String(), "OPERATIONAL") {
+			t.Errorf("%s: %d %s, want the params set by the interceptor", transport.path, w.Code, w.Body)
+		}
+		want := []string{"all libreria-a.GetSystemStatus", "namespace libreria-a.GetSystemStatus", "method libreria-a.GetSystemStatus"}
+		if !reflect.DeepEqual(order, want) {
+			t.Errorf("%s: interceptors ran %q, want %q", transport.path, order, want)
+		}
+	}
+}
+
+func TestInterceptorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"api error", &APIError{StatusCode: http.StatusUnauthorized, Code: "unauthorized", Message: "no token"}, http.StatusUnauthorized, "unauthorized"},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, CodeLibraryError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			useForTest(t, "libreria-a.Transfer", func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+				return nil, tt.err
+			})
+			useForTest(t, "libreria-a.Transfer", func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+				called = true
+				return next(ctx, info)
+			})
+			w := post(t, "/liba/Transfer", `{"params": {}}`)
+			var body struct{ Error APIError }
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if got := body.Error; w.Code != tt.status || got.Code != tt.code || got.Library != "libreria-a" || got.Method != "Transfer" {
+				t.Errorf("%d %+v, want %d %s naming libreria-a.Transfer", w.Code, got, tt.status, tt.code)
+			}
+			if called {
+				t.Errorf("the inner interceptor ran after the outer one failed")
+			}
+		})
+	}
+}
+
+func TestInterceptorSharedError(t *testing.T) {
+	errUnauthorized := &APIError{StatusCode: http.StatusUnauthorized, Code: "unauthorized", Message: "no token"}
+	useForTest(t, "libreria-a", func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+		return nil, errUnauthorized
+	})
+
+	routes := []struct{ path, method string }{
+		{"/liba/Transfer", "Transfer"},
+		{"/liba/GetSystemStatus", "GetSystemStatus"},
+	}
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		for _, route := range routes {
+			go func() {
+				w := post(t, route.path, `{"params": {}}`)
+				var body struct{ Error APIError }
+				json.Unmarshal(w.Body.Bytes(), &body)
+				if w.Code != http.StatusUnauthorized || body.Error.Method != route.method {
+					done <- fmt.Errorf("%s: %d %+v, want 401 naming %s", route.path, w.Code, body.Error, route.method)
+					return
+				}
+				done <- nil
+			}()
+		}
+	}
+	for i := 0; i < 10*len(routes); i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+	if errUnauthorized.Library != "" || errUnauthorized.Method != "" {
+		t.Errorf("the shared error was modified: %+v", errUnauthorized)
+	}
+}
+
+func TestUseHTTP(t *testing.T) {
+	header := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Trace", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	mux := http.NewServeMux()
+	RegisterHandlers(mux)
+	serve := func(path string) []string {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(`{"params": {"code": "ADMIN123"}}`)))
+		return w.Header().Values("X-Trace")
+	}
+
+	useHTTPForTest(t, "*", header("all"))
+	if got := serve("/liba/GetSystemStatus"); !reflect.DeepEqual(got, []string{"all"}) {
+		t.Fatalf("X-Trace %q", got)
+	}
+	// Middleware added after the first request is picked up by the route.
+	useHTTPForTest(t, "libreria-a.GetSystemStatus", header("method"))
+	useHTTPForTest(t, "libreria-b", header("other library"))
+	if got := serve("/liba/GetSystemStatus"); !reflect.DeepEqual(got, []string{"all", "method"}) {
+		t.Errorf("X-Trace %q, want the middleware in the order added", got)
+	}
+	if got := serve("/liba/GetUserBalance"); !reflect.DeepEqual(got, []string{"all"}) {
+		t.Errorf("GetUserBalance X-Trace %q", got)
+	}
+	if got := serve("/rpc"); got != nil {
+		t.Errorf("/rpc X-Trace %q, want none", got)
+	}
+}
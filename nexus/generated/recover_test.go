@@ -0,0 +1,150 @@
+package generated
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// guardedCode runs call like a library method and returns the status and
+// error code it is answered with, "200" on success.
+func guardedCode(call callFunc) string {
+	_, err := runGuarded(context.Background(), "libreria-a", "Flaky", nil, call)
+	if err == nil {
+		return "200"
+	}
+	ce := asCallError(err)
+	return http.StatusText(ce.Status) + " " + ce.API.Code
+}
+
+func configureBreakerForTest(t *testing.T, cb CircuitBreaker) {
+	t.Helper()
+	ConfigureCircuitBreaker(cb)
+	out := log.Writer()
+	log.SetOutput(io.Discard) // Panic stacks
+	t.Cleanup(func() {
+		ConfigureCircuitBreaker(CircuitBreaker{})
+		log.SetOutput(out)
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	configureBreakerForTest(t, CircuitBreaker{Threshold: 2, Cooldown: cooldown})
+
+	calls := 0
+	panics := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		calls++
+		panic("boom")
+	}
+	succeeds := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		calls++
+		return map[string]interface{}{}, nil
+	}
+	fails := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		calls++
+		return nil, errors.New("not a panic")
+	}
+
+	steps := []struct {
+		name string
+		call callFunc
+		want string
+	}{
+		{"panic", panics, "Internal Server Error internal_error"},
+		{"errors do not count", fails, "Internal Server Error library_error"},
+		{"threshold reached", panics, "Internal Server Error internal_error"},
+		{"open", succeeds, "Service Unavailable circuit_open"},
+	}
+	for _, s := range steps {
+		if got := guardedCode(s.call); got != s.want {
+			t.Fatalf("%s: %s, want %s", s.name, got, s.want)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("%d calls ran, want 3: an open circuit does not run the method", calls)
+	}
+
+	// After the cooldown a single probe runs; the other calls keep failing
+	// until it returns.
+	time.Sleep(cooldown)
+	probing, release := make(chan struct{}), make(chan struct{})
+	done := make(chan string)
+	go func() {
+		done <- guardedCode(func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+			close(probing)
+			<-release
+			return map[string]interface{}{}, nil
+		})
+	}()
+	<-probing
+	_, err := runGuarded(context.Background(), "libreria-a", "Flaky", nil, succeeds)
+	if ce := asCallError(err); err == nil || ce.API.Code != CodeCircuitOpen || !strings.Contains(ce.API.Message, "a probe call is running") {
+		t.Errorf("call during the probe: %v", err)
+	}
+	close(release)
+	if got := <-done; got != "200" {
+		t.Fatalf("probe: %s", got)
+	}
+	if got := guardedCode(succeeds); got != "200" {
+		t.Errorf("after a successful probe: %s, want the circuit closed", got)
+	}
+
+	// A panicking probe opens the circuit again for a whole cooldown.
+	guardedCode(panics)
+	guardedCode(panics)
+	time.Sleep(cooldown)
+	if got := guardedCode(panics); got != "Internal Server Error internal_error" {
+		t.Fatalf("probe: %s", got)
+	}
+	if got := guardedCode(succeeds); got != "Service Unavailable circuit_open" {
+		t.Errorf("after a panicking probe: %s, want the circuit open", got)
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	tests := []struct {
+		name string
+		cb   CircuitBreaker
+		wait time.Duration // Between the two panics
+		want string        // Then
+	}{
+		{"disabled", CircuitBreaker{}, 0, "200"},
+		{"within the window", CircuitBreaker{Threshold: 2, Window: time.Minute}, 0, "Service Unavailable circuit_open"},
+		{"outside the window", CircuitBreaker{Threshold: 2, Window: 20 * time.Millisecond}, 30 * time.Millisecond, "200"},
+	}
+	panics := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		panic("boom")
+	}
+	succeeds := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configureBreakerForTest(t, tt.cb)
+			guardedCode(panics)
+			time.Sleep(tt.wait)
+			guardedCode(panics)
+			if got := guardedCode(succeeds); got != tt.want {
+				t.Errorf("%s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	configureBreakerForTest(t, CircuitBreaker{})
+	useForTest(t, "libreria-a.GetSystemStatus", func(ctx context.Context, info CallInfo, next CallHandler) (map[string]interface{}, error) {
+		panic("secret detail")
+	})
+	w := post(t, "/liba/GetSystemStatus", `{"params": {"code": "ADMIN123"}}`)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "secret detail") ||
+		!strings.Contains(w.Body.String(), `"correlation_id":`) {
+		t.Errorf("%d %s, want a 500 with a correlation ID and without the panic value", w.Code, w.Body)
+	}
+}
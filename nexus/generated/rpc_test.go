@@ -0,0 +1,95 @@
+package generated
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRPC(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string // Response body, empty for 204
+	}{
+		{"call", `{"jsonrpc": "2.0", "id": 1, "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}}`, http.StatusOK,
+			`{"jsonrpc":"2.0","result":{"result":"OPERATIONAL"},"id":1}`},
+		{"string id", `{"jsonrpc": "2.0", "id": "a", "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}}`, http.StatusOK,
+			`{"jsonrpc":"2.0","result":{"result":"OPERATIONAL"},"id":"a"}`},
+		{"notification", `{"jsonrpc": "2.0", "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}}`, http.StatusNoContent, ""},
+		{"failed notification", `{"jsonrpc": "2.0", "method": "liba.Nope"}`, http.StatusNoContent, ""},
+		{"missing param", `{"jsonrpc": "2.0", "id": 2, "method": "liba.GetSystemStatus", "params": {}}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"param code not found in request params","data":{"code":"missing_param","message":"param code not found in request params","parameter":"code","library":"libreria-a","method":"GetSystemStatus"}},"id":2}`},
+		{"library error", `{"jsonrpc": "2.0", "id": 3, "method": "liba.GetSystemStatus", "params": {"code": "x"}}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32000,"message":"invalid admin code","data":{"code":"library_error","message":"invalid admin code","library":"libreria-a","method":"GetSystemStatus"}},"id":3}`},
+		{"unknown method", `{"jsonrpc": "2.0", "id": 4, "method": "liba.Nope"}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"liba.Nope"},"id":4}`},
+		{"positional params", `{"jsonrpc": "2.0", "id": 5, "method": "liba.GetSystemStatus", "params": ["ADMIN123"]}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params must be an object of named params"},"id":5}`},
+		{"wrong version", `{"jsonrpc": "1.0", "id": 6, "method": "liba.GetSystemStatus"}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\" and method a non-empty string"},"id":6}`},
+		{"object id", `{"jsonrpc": "2.0", "id": {}, "method": "liba.GetSystemStatus"}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"id must be a string, number or null"},"id":null}`},
+		{"parse error", `{"jsonrpc": `, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"unexpected EOF"},"id":null}`},
+		{"batch", `[
+			{"jsonrpc": "2.0", "id": 1, "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}},
+			{"jsonrpc": "2.0", "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}},
+			1,
+			{"jsonrpc": "2.0", "id": 2, "method": "liba.Nope"}
+		]`, http.StatusOK,
+			`[{"jsonrpc":"2.0","result":{"result":"OPERATIONAL"},"id":1},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request must be an object"},"id":null},` +
+				`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"liba.Nope"},"id":2}]`},
+		{"batch of notifications", `[{"jsonrpc": "2.0", "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}}]`, http.StatusNoContent, ""},
+		{"empty batch", `[]`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"empty batch"},"id":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, "/rpc", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want == "" {
+				if w.Body.Len() != 0 {
+					t.Errorf("body %s, want none", w.Body)
+				}
+				return
+			}
+			if got, want := mustCompact(t, w.Body.String()), mustCompact(t, tt.want); got != want {
+				t.Errorf("body\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestRPCBatchLimit(t *testing.T) {
+	ConfigureBatch(BatchLimits{MaxCalls: 2})
+	t.Cleanup(func() { ConfigureBatch(BatchLimits{}) })
+
+	call := `{"jsonrpc": "2.0", "id": 1, "method": "liba.GetSystemStatus", "params": {"code": "ADMIN123"}}`
+	w := post(t, "/rpc", "["+call+","+call+","+call+"]")
+	want := `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch has 3 requests, at most 2 allowed"},"id":null}`
+	if got, want := mustCompact(t, w.Body.String()), mustCompact(t, want); w.Code != http.StatusOK || got != want {
+		t.Errorf("%d %s, want 200 %s", w.Code, got, want)
+	}
+	if w := post(t, "/rpc", "["+call+","+call+"]"); w.Code != http.StatusOK || w.Body.String()[0] != '[' {
+		t.Errorf("batch at the limit: %d %s", w.Code, w.Body)
+	}
+}
+
+// mustCompact re-encodes a JSON document with sorted keys and no spaces.
+func mustCompact(t *testing.T, s string) string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -1,16 +1,20 @@
+// Code generated by nexus-cli. DO NOT EDIT.
+
 package generated
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
+	"strings"
 )
 
 type Client struct {
-	BaseURL    string
-	HTTP       *http.Client
-	LibreriaA  *LibreriaAClient
+	BaseURL   string
+	HTTP      *http.Client
+	LibreriaA *LibreriaAClient
 }
 
 func NewClient(baseURL string) *Client {
@@ -22,65 +26,196 @@ func NewClient(baseURL string) *Client {
 	return c
 }
 
-type LibreriaAClient struct {
+// call posts params wrapped in the {"params": ...} envelope to path and
+// decodes the JSON response body into out. ctx cancels the HTTP request and
+// is propagated by the server to library functions taking a context.
+func (c *Client) call(ctx context.Context, path string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"params": params})
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// decodeError turns a failed response into an *APIError. Responses without
+// the {"error": ...} envelope keep their body text as the message.
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var envelope struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		envelope.Error = &APIError{Code: CodeHTTPError, Message: msg}
+	}
+	envelope.Error.StatusCode = resp.StatusCode
+	return envelope.Error
+}
+
+// Batch collects calls sent in one request to POST /_nexus/batch:
+//
+//	results, err := client.Batch().
+//		Add("libreria-a", "GetSystemStatus", LibreriaAGetSystemStatusRequest{Code: "ADMIN"}).
+//		Add("libreria-a", "Transfer", LibreriaATransferRequest{...}).
+//		Do(ctx)
+//
+// Calls run in order and stop at the first failure unless Parallel is used.
+type Batch struct {
 	client *Client
+	req    BatchRequest
+	err    error
+}
+
+// Batch starts an empty batch.
+func (c *Client) Batch() *Batch {
+	return &Batch{client: c}
 }
 
+// Add appends a call of method (Transfer or BankService.Transfer) of the
+// library namespace. params is a request struct or a params map.
+func (b *Batch) Add(namespace, method string, params interface{}) *Batch {
+	call := BatchCall{Namespace: namespace, Method: method}
+	if data, err := json.Marshal(params); err != nil {
+		b.err = err
+	} else if err := json.Unmarshal(data, &call.Params); err != nil && b.err == nil {
+		b.err = err
+	}
+	b.req.Calls = append(b.req.Calls, call)
+	return b
+}
 
-func (c *LibreriaAClient) GetUserBalance(req GenericRequest) (interface{}, error) {
-	body, _ := json.Marshal(req)
-	resp, err := c.client.HTTP.Post(c.client.BaseURL+"/liba/GetUserBalance", "application/json", bytes.NewBuffer(body))
+// Parallel runs the calls concurrently, at most concurrency at a time (0
+// uses the server default), and runs all of them even when some fail.
+func (b *Batch) Parallel(concurrency int) *Batch {
+	b.req.Parallel, b.req.Concurrency = true, concurrency
+	return b
+}
+
+// Do sends the batch and returns one result per call, in the order they were
+// added. A failed call does not fail Do: check each result's Err.
+func (b *Batch) Do(ctx context.Context) ([]BatchResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	body, err := json.Marshal(b.req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, b.client.BaseURL+"/_nexus/batch", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.HTTP.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("server error: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
 	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var out BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return nil, err
 	}
-	return result["result"], nil
+	for _, r := range out.Results {
+		if r.Error != nil {
+			r.Error.StatusCode = r.Status
+		}
+	}
+	return out.Results, nil
 }
 
-func (c *LibreriaAClient) Transfer(req GenericRequest) (interface{}, error) {
-	body, _ := json.Marshal(req)
-	resp, err := c.client.HTTP.Post(c.client.BaseURL+"/liba/Transfer", "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
+// Err returns the *APIError of a failed call, nil on success.
+func (r BatchResult) Err() error {
+	if r.Error != nil {
+		return r.Error
 	}
-	defer resp.Body.Close()
+	return nil
+}
+
+// Decode decodes the response of a successful call into out, such as a
+// *LibreriaATransferResponse, or returns its error.
+func (r BatchResult) Decode(out interface{}) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Result, out)
+}
+
+type LibreriaAClient struct {
+	client *Client
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("server error: %d", resp.StatusCode)
+// GetUserBalance calls liba.GetUserBalance with typed parameters.
+func (c *LibreriaAClient) GetUserBalance(ctx context.Context, req LibreriaAGetUserBalanceRequest) (*LibreriaAGetUserBalanceResponse, error) {
+	var result LibreriaAGetUserBalanceResponse
+	if err := c.client.call(ctx, "/liba/GetUserBalance", req, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
+// GetUserBalanceGeneric calls liba.GetUserBalance with a free-form params map.
+func (c *LibreriaAClient) GetUserBalanceGeneric(ctx context.Context, req GenericRequest) (interface{}, error) {
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.client.call(ctx, "/liba/GetUserBalance", req.Params, &result); err != nil {
 		return nil, err
 	}
 	return result["result"], nil
 }
 
-func (c *LibreriaAClient) GetSystemStatus(req GenericRequest) (interface{}, error) {
-	body, _ := json.Marshal(req)
-	resp, err := c.client.HTTP.Post(c.client.BaseURL+"/liba/GetSystemStatus", "application/json", bytes.NewBuffer(body))
-	if err != nil {
+// Transfer calls liba.Transfer with typed parameters.
+func (c *LibreriaAClient) Transfer(ctx context.Context, req LibreriaATransferRequest) (*LibreriaATransferResponse, error) {
+	var result LibreriaATransferResponse
+	if err := c.client.call(ctx, "/liba/Transfer", req, &result); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("server error: %d", resp.StatusCode)
+// TransferGeneric calls liba.Transfer with a free-form params map.
+func (c *LibreriaAClient) TransferGeneric(ctx context.Context, req GenericRequest) (interface{}, error) {
+	var result map[string]interface{}
+	if err := c.client.call(ctx, "/liba/Transfer", req.Params, &result); err != nil {
+		return nil, err
 	}
+	return result["result"], nil
+}
 
+// GetSystemStatus calls liba.GetSystemStatus with typed parameters.
+func (c *LibreriaAClient) GetSystemStatus(ctx context.Context, req LibreriaAGetSystemStatusRequest) (*LibreriaAGetSystemStatusResponse, error) {
+	var result LibreriaAGetSystemStatusResponse
+	if err := c.client.call(ctx, "/liba/GetSystemStatus", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetSystemStatusGeneric calls liba.GetSystemStatus with a free-form params map.
+func (c *LibreriaAClient) GetSystemStatusGeneric(ctx context.Context, req GenericRequest) (interface{}, error) {
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.client.call(ctx, "/liba/GetSystemStatus", req.Params, &result); err != nil {
 		return nil, err
 	}
 	return result["result"], nil
 }
-
@@ -1,75 +1,90 @@
+// Code generated by nexus-cli. DO NOT EDIT.
+
 package generated
 
 import (
+	"context"
 	"encoding/json"
-	"net/http"
-	"github.com/japablazatww/libreria-a"
+	"errors"
 	"fmt"
-	"strings"
-	"unicode"
+	"net/http"
+
+	liba "github.com/japablazatww/libreria-a"
 )
 
 func RegisterHandlers(mux *http.ServeMux) {
-	
-	mux.HandleFunc("/liba/GetUserBalance", handleGetUserBalance)
-	
-	mux.HandleFunc("/liba/Transfer", handleTransfer)
-	
-	mux.HandleFunc("/liba/GetSystemStatus", handleGetSystemStatus)
-	
+	mux.HandleFunc("/openapi.json", handleOpenAPI)
+	mux.HandleFunc("/_nexus/catalog", discoveryHandler(handleCatalog))
+	mux.HandleFunc("/_nexus/services/{namespace}/{method...}", discoveryHandler(handleCatalogService))
+	mux.HandleFunc("/_nexus/search", discoveryHandler(handleCatalogSearch))
+	mux.HandleFunc("/rpc", handleRPC)
+	mux.HandleFunc("/_nexus/batch", handleBatch)
+	mux.Handle("/liba/GetUserBalance", routeHandler("libreria-a", "GetUserBalance", handleLibreriaAGetUserBalance))
+	mux.Handle("/liba/Transfer", routeHandler("libreria-a", "Transfer", handleLibreriaATransfer))
+	mux.Handle("/liba/GetSystemStatus", routeHandler("libreria-a", "GetSystemStatus", handleLibreriaAGetSystemStatus))
 }
 
 func getParam(params map[string]interface{}, name string) (interface{}, error) {
-	// 1. Try exact match
-	if v, ok := params[name]; ok { return v, nil }
-
-	// 2. Case-Insensitive Match
-	// Create a normalized map where keys are lowercased (without underscores for fuzzy matching might be better, but let's stick to lower case first)
-	// For performance in a real app this should be done once per request, but for PoC this function is fine.
-	target := strings.ToLower(name)
-	targetNoUnderscore := strings.ReplaceAll(target, "_", "")
-
-	for k, v := range params {
-		kLower := strings.ToLower(k)
-		if kLower == target { return v, nil }
-		
-		// 3. Fuzzy match (ignoring underscores) e.g. "user_id" vs "userid"
-		kNoUnderscore := strings.ReplaceAll(kLower, "_", "")
-		if kNoUnderscore == targetNoUnderscore { return v, nil }
+	// Exact, case-insensitive or underscore-insensitive match ("user_id" vs "userID")
+	if k, ok := matchKey(params, name); ok {
+		return params[k], nil
 	}
-
 	return nil, fmt.Errorf("param %s not found in request params", name)
 }
 
-func toSnakeCase(str string) string {
-	var matchFirstCap = unicode.IsUpper
-	var result strings.Builder
-	for i, r := range str {
-		if matchFirstCap(r) && i > 0 {
-			result.WriteRune('_')
-		}
-		result.WriteRune(unicode.ToLower(r))
-	}
-	return result.String()
+// writeError answers a failed call with the {"error": ...} envelope. The
+// message comes from cause; a *ParamError cause also fills in the exact
+// parameter path and the expected and received types.
+func writeError(w http.ResponseWriter, status int, apiErr *APIError, cause error) {
+	writeCallError(w, newCallError(status, apiErr, cause))
 }
 
-func toPascalCase(str string) string {
-	if len(str) == 0 { return "" }
-	return strings.ToUpper(str[:1]) + str[1:]
+// callError is a failed library call: the HTTP status and error envelope it
+// is answered with, and the error that caused it.
+type callError struct {
+	Status int
+	API    *APIError
+	Cause  error
 }
 
+func (e *callError) Error() string { return e.Cause.Error() }
+func (e *callError) Unwrap() error { return e.Cause }
+
+// newCallError fills apiErr the way writeError does and wraps cause.
+func newCallError(status int, apiErr *APIError, cause error) *callError {
+	apiErr.Message = cause.Error()
+	var pe *ParamError
+	if errors.As(cause, &pe) {
+		apiErr.Parameter = pe.Param
+		apiErr.Details = map[string]interface{}{"expected": pe.Expected, "got": pe.Got}
+		if pe.Reason != "" {
+			apiErr.Details["reason"] = pe.Reason
+		}
+	}
+	return &callError{Status: status, API: apiErr, Cause: cause}
+}
 
+// callFunc runs a library function with the params of a request and returns
+// its response fields, or a *callError.
+type callFunc func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error)
 
+// serveCall answers POST {"params": {...}} with the result of call.
+func serveCall(w http.ResponseWriter, r *http.Request, library, method string, call callFunc) {
+	fail := func(status int, code string, err error) {
+		writeError(w, status, &APIError{Code: code, Library: library, Method: method}, err)
+	}
 
-func handleGetUserBalance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		w.Header().Set("Allow", "POST")
+		fail(http.StatusMethodNotAllowed, CodeMethodNotAllowed, fmt.Errorf("method %s not allowed, use POST", r.Method))
 		return
 	}
 
 	var req GenericRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		fail(http.StatusBadRequest, CodeInvalidRequest, fmt.Errorf("invalid request body: %w", err))
 		return
 	}
 
@@ -77,225 +92,211 @@ func handleGetUserBalance(w http.ResponseWriter, r *http.Request) {
 	if params == nil {
 		params = make(map[string]interface{})
 	}
+	result, err := call(r.Context(), params)
+	if err != nil {
+		writeCallError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// asCallError returns the *callError of err. An *APIError with a
+// StatusCode, as returned by interceptors, keeps its status; other errors
+// are a 500 library_error.
+func asCallError(err error) *callError {
+	var ce *callError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return &callError{Status: apiErr.StatusCode, API: apiErr, Cause: err}
+	}
+	return newCallError(http.StatusInternalServerError, &APIError{Code: CodeLibraryError}, err)
+}
+
+// writeCallError answers with the status and envelope of asCallError(err).
+func writeCallError(w http.ResponseWriter, err error) {
+	ce := asCallError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(ce.Status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": ce.API})
+}
+
+// errorStatusLibreriaA maps the sentinel errors and error types declared
+// by libreria-a to an HTTP status and code (see the "errors" section of
+// the catalog); any other error is a 500 library_error.
+func errorStatusLibreriaA(err error) (int, string) {
+	return http.StatusInternalServerError, CodeLibraryError
+}
+
+func handleLibreriaAGetUserBalance(w http.ResponseWriter, r *http.Request) {
+	serveCall(w, r, "libreria-a", "GetUserBalance", callLibreriaAGetUserBalance)
+}
+
+// callLibreriaAGetUserBalance runs liba.GetUserBalance with the params of a request,
+// through the interceptors added with Use.
+func callLibreriaAGetUserBalance(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	return intercept(ctx, "libreria-a", "GetUserBalance", params, runLibreriaAGetUserBalance)
+}
+
+func runLibreriaAGetUserBalance(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	fail := func(status int, code, param string, err error) (map[string]interface{}, error) {
+		return nil, newCallError(status, &APIError{Code: code, Parameter: param, Library: "libreria-a", Method: "GetUserBalance"}, err)
+	}
 
 	// Dynamic Parameter Extraction
-	
+
 	val_userID, err := getParam(params, "userID")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "userID", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_userID string
-	
-	switch v := val_userID.(type) {
-	case string:
-		arg_userID = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("userID", val_userID, &arg_userID); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "userID", err)
 	}
-	
+
 	val_accountID, err := getParam(params, "accountID")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "accountID", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_accountID string
-	
-	switch v := val_accountID.(type) {
-	case string:
-		arg_accountID = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("accountID", val_accountID, &arg_accountID); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "accountID", err)
 	}
-	
 
 	// Call underlying library
-	res, err := liba.GetUserBalance(
+	out_0, err := liba.GetUserBalance(
 		arg_userID,
 		arg_accountID,
-		
 	)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		status, code := errorStatusLibreriaA(err)
+		return fail(status, code, "", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"result": res})
+	return map[string]interface{}{
+		"result": out_0,
+	}, nil
 }
 
-func handleTransfer(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+func handleLibreriaATransfer(w http.ResponseWriter, r *http.Request) {
+	serveCall(w, r, "libreria-a", "Transfer", callLibreriaATransfer)
+}
 
-	var req GenericRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+// callLibreriaATransfer runs liba.Transfer with the params of a request,
+// through the interceptors added with Use.
+func callLibreriaATransfer(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	return intercept(ctx, "libreria-a", "Transfer", params, runLibreriaATransfer)
+}
 
-	params := req.Params
-	if params == nil {
-		params = make(map[string]interface{})
+func runLibreriaATransfer(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	fail := func(status int, code, param string, err error) (map[string]interface{}, error) {
+		return nil, newCallError(status, &APIError{Code: code, Parameter: param, Library: "libreria-a", Method: "Transfer"}, err)
 	}
 
 	// Dynamic Parameter Extraction
-	
+
 	val_sourceAccount, err := getParam(params, "sourceAccount")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "sourceAccount", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_sourceAccount string
-	
-	switch v := val_sourceAccount.(type) {
-	case string:
-		arg_sourceAccount = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("sourceAccount", val_sourceAccount, &arg_sourceAccount); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "sourceAccount", err)
 	}
-	
+
 	val_destAccount, err := getParam(params, "destAccount")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "destAccount", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_destAccount string
-	
-	switch v := val_destAccount.(type) {
-	case string:
-		arg_destAccount = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("destAccount", val_destAccount, &arg_destAccount); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "destAccount", err)
 	}
-	
+
 	val_amount, err := getParam(params, "amount")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "amount", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_amount float64
-	
-	switch v := val_amount.(type) {
-	case float64:
-		arg_amount = v
-	
-	
-	case string:
-		// Try to handle string if needed, currently empty for strict types but avoided duplicate case
-	
-	default:
-		_ = v
+	if err := coerceParam("amount", val_amount, &arg_amount); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "amount", err)
 	}
-	
+
 	val_currency, err := getParam(params, "currency")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "currency", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_currency string
-	
-	switch v := val_currency.(type) {
-	case string:
-		arg_currency = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("currency", val_currency, &arg_currency); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "currency", err)
 	}
-	
 
 	// Call underlying library
-	res, err := liba.Transfer(
+	out_0, err := liba.Transfer(
 		arg_sourceAccount,
 		arg_destAccount,
 		arg_amount,
 		arg_currency,
-		
 	)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		status, code := errorStatusLibreriaA(err)
+		return fail(status, code, "", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"result": res})
+	return map[string]interface{}{
+		"result": out_0,
+	}, nil
 }
 
-func handleGetSystemStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+func handleLibreriaAGetSystemStatus(w http.ResponseWriter, r *http.Request) {
+	serveCall(w, r, "libreria-a", "GetSystemStatus", callLibreriaAGetSystemStatus)
+}
 
-	var req GenericRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+// callLibreriaAGetSystemStatus runs liba.GetSystemStatus with the params of a request,
+// through the interceptors added with Use.
+func callLibreriaAGetSystemStatus(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	return intercept(ctx, "libreria-a", "GetSystemStatus", params, runLibreriaAGetSystemStatus)
+}
 
-	params := req.Params
-	if params == nil {
-		params = make(map[string]interface{})
+func runLibreriaAGetSystemStatus(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	fail := func(status int, code, param string, err error) (map[string]interface{}, error) {
+		return nil, newCallError(status, &APIError{Code: code, Parameter: param, Library: "libreria-a", Method: "GetSystemStatus"}, err)
 	}
 
 	// Dynamic Parameter Extraction
-	
+
 	val_code, err := getParam(params, "code")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return fail(http.StatusBadRequest, CodeMissingParam, "code", err)
 	}
-	
-	// Type Assertion/Conversion (Simplified for PoC - assumes correct JSON types or simple string conversions)
+
 	var arg_code string
-	
-	switch v := val_code.(type) {
-	case string:
-		arg_code = v
-	
-	
-	default:
-		_ = v
+	if err := coerceParam("code", val_code, &arg_code); err != nil {
+		return fail(http.StatusBadRequest, CodeInvalidParam, "code", err)
 	}
-	
 
 	// Call underlying library
-	res, err := liba.GetSystemStatus(
+	out_0, err := liba.GetSystemStatus(
 		arg_code,
-		
 	)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		status, code := errorStatusLibreriaA(err)
+		return fail(status, code, "", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"result": res})
+	return map[string]interface{}{
+		"result": out_0,
+	}, nil
 }
-
@@ -1,6 +1,42 @@
+// Code generated by nexus-cli. DO NOT EDIT.
+
 package generated
 
 // GenericRequest is the standard request envelope
 type GenericRequest struct {
 	Params map[string]interface{} `json:"params"`
 }
+
+// LibreriaAGetUserBalanceRequest holds the parameters of liba.GetUserBalance.
+type LibreriaAGetUserBalanceRequest struct {
+	UserID    string `json:"user_id"`
+	AccountID string `json:"account_id"`
+}
+
+// LibreriaAGetUserBalanceResponse holds the result of liba.GetUserBalance.
+type LibreriaAGetUserBalanceResponse struct {
+	Result float64 `json:"result"`
+}
+
+// LibreriaATransferRequest holds the parameters of liba.Transfer.
+type LibreriaATransferRequest struct {
+	SourceAccount string  `json:"source_account"`
+	DestAccount   string  `json:"dest_account"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+// LibreriaATransferResponse holds the result of liba.Transfer.
+type LibreriaATransferResponse struct {
+	Result string `json:"result"`
+}
+
+// LibreriaAGetSystemStatusRequest holds the parameters of liba.GetSystemStatus.
+type LibreriaAGetSystemStatusRequest struct {
+	Code string `json:"code"`
+}
+
+// LibreriaAGetSystemStatusResponse holds the result of liba.GetSystemStatus.
+type LibreriaAGetSystemStatusResponse struct {
+	Result string `json:"result"`
+}
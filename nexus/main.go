@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
 
 	"github.com/japablazatww/centralnexus/nexus/generated"
 )
 
 func main() {
+	// Interceptors run around every library call, whatever the transport
+	// (REST, /rpc, /_nexus/batch, gRPC). Patterns: "*", a namespace such as
+	// "libreria-a" or a method such as "libreria-a.Transfer".
+	generated.Use("*", logCalls)
+
+	// Panics are recovered per call; a method that panics 5 times within a
+	// minute is disabled for 30 seconds.
+	generated.ConfigureCircuitBreaker(generated.CircuitBreaker{Threshold: 5, Window: time.Minute, Cooldown: 30 * time.Second})
+
 	mux := http.NewServeMux()
 
 	// Register generated handlers
@@ -19,9 +34,40 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
+	// gRPC: the same library calls, described by generated/nexus.proto
+	grpcServer := grpc.NewServer()
+	if err := generated.RegisterGRPC(grpcServer); err != nil {
+		fmt.Printf("Error registering gRPC services: %v\n", err)
+		return
+	}
+	grpcPort := ":9090"
+	go func() {
+		lis, err := net.Listen("tcp", grpcPort)
+		if err != nil {
+			fmt.Printf("Error starting gRPC server: %v\n", err)
+			return
+		}
+		fmt.Printf("[Nexus] gRPC server listening on %s\n", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			fmt.Printf("Error serving gRPC: %v\n", err)
+		}
+	}()
+
 	port := ":8080"
 	fmt.Printf("[Nexus] Server listening on %s\n", port)
 	if err := http.ListenAndServe(port, mux); err != nil {
 		fmt.Printf("Error starting server: %v\n", err)
 	}
 }
+
+// logCalls logs the duration and outcome of every library call.
+func logCalls(ctx context.Context, info generated.CallInfo, next generated.CallHandler) (map[string]interface{}, error) {
+	start := time.Now()
+	result, err := next(ctx, info)
+	if err != nil {
+		log.Printf("[Nexus] %s.%s failed in %s: %v", info.Namespace, info.Method, time.Since(start), err)
+	} else {
+		log.Printf("[Nexus] %s.%s in %s", info.Namespace, info.Method, time.Since(start))
+	}
+	return result, err
+}
@@ -0,0 +1,268 @@
+// Package search ranks catalog services against search filters for
+// nexus-cli search. The /_nexus/search endpoint of the generated server
+// ranks with a copy of it, emitted from templates/search.go.tmpl, so both
+// return the same results in the same order: keep the two in step.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Query holds the search filters; every non-empty filter must match.
+// Param and Type apply to the same parameter, restricted to inputs or
+// outputs by Inputs/Outputs (both or neither: any side).
+type Query struct {
+	Param   string `json:"param,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Method  string `json:"method,omitempty"`
+	Text    string `json:"text,omitempty"` // Words looked up in the doc comment
+	Inputs  bool   `json:"inputs,omitempty"`
+	Outputs bool   `json:"outputs,omitempty"`
+}
+
+// Empty reports whether no filter is set, Inputs/Outputs only narrow others.
+func (q Query) Empty() bool {
+	return q.Param == "" && q.Type == "" && q.Method == "" && q.Text == ""
+}
+
+// Service is the part of a catalog service entry the filters match on. Its
+// JSON form is the one of the catalog.
+type Service struct {
+	Namespace   string  `json:"namespace"`
+	Receiver    string  `json:"receiver,omitempty"`
+	Method      string  `json:"method"`
+	Description string  `json:"description"`
+	Inputs      []Param `json:"inputs"`
+	Outputs     []Param `json:"outputs"`
+}
+
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// FullMethod returns Method, prefixed with the receiver for service methods.
+func (s Service) FullMethod() string {
+	if s.Receiver != "" {
+		return s.Receiver + "." + s.Method
+	}
+	return s.Method
+}
+
+// Match is a service that matched every filter of a query.
+type Match struct {
+	Index        int    // Of the service in the slice given to Rank
+	MatchedParam string // Best param for the Param and Type filters
+	ParamType    string // "Input" or "Output"
+	Score        int    // Sum of the filter scores, higher is a closer match
+}
+
+// Scores of FuzzyScore and TypeScore, from the closest match down.
+const (
+	ScoreExact     = 100
+	ScorePrefix    = 80
+	ScoreElement   = 70 // TypeScore: element type of a pointer, slice, array or map
+	ScoreSubstring = 60
+	ScoreFuzzy     = 50 // Minus 10 per edit
+)
+
+// Rank returns the services matching every filter of q, best ranked first
+// and by namespace.method on ties (every service for an empty query).
+func Rank(services []Service, q Query) []Match {
+	var matches []Match
+	for i, svc := range services {
+		m := Match{Index: i}
+
+		if q.Param != "" || q.Type != "" {
+			best := 0
+			check := func(params []Param, side string) {
+				for _, p := range params {
+					score := 0
+					if q.Param != "" {
+						if score = FuzzyScore(q.Param, p.Name); score == 0 {
+							continue
+						}
+					}
+					if q.Type != "" {
+						typeScore := TypeScore(q.Type, p.Type)
+						if typeScore == 0 {
+							continue
+						}
+						score += typeScore
+					}
+					if score > best {
+						best = score
+						m.MatchedParam, m.ParamType = p.Name, side
+					}
+				}
+			}
+			if q.Inputs || !q.Outputs {
+				check(svc.Inputs, "Input")
+			}
+			if q.Outputs || !q.Inputs {
+				check(svc.Outputs, "Output")
+			}
+			if best == 0 {
+				continue
+			}
+			m.Score += best
+		}
+
+		if q.Method != "" {
+			score := max(FuzzyScore(q.Method, svc.Method), FuzzyScore(q.Method, svc.FullMethod()),
+				FuzzyScore(q.Method, svc.Namespace+"."+svc.FullMethod()))
+			if score == 0 {
+				continue
+			}
+			m.Score += score
+		}
+
+		if q.Text != "" {
+			score := TextScore(q.Text, svc.Description)
+			if score == 0 {
+				continue
+			}
+			m.Score += score
+		}
+
+		matches = append(matches, m)
+	}
+
+	id := func(m Match) string {
+		return services[m.Index].Namespace + "." + services[m.Index].FullMethod()
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return id(matches[i]) < id(matches[j])
+	})
+	return matches
+}
+
+// FuzzyScore rates how well candidate matches query, ignoring case and
+// underscores: exact, prefix, substring, or within a few edits (one for
+// queries of 4 to 6 characters, two for longer ones). 0 means no match.
+func FuzzyScore(query, candidate string) int {
+	q, c := normalize(query), normalize(candidate)
+	switch {
+	case q == "":
+		return 0
+	case q == c:
+		return ScoreExact
+	case strings.HasPrefix(c, q):
+		return ScorePrefix
+	case strings.Contains(c, q):
+		return ScoreSubstring
+	}
+	allowed := 0
+	switch n := len([]rune(q)); {
+	case n > 6:
+		allowed = 2
+	case n > 3:
+		allowed = 1
+	}
+	if d := EditDistance(q, c); d <= allowed {
+		return ScoreFuzzy - 10*d
+	}
+	return 0
+}
+
+// TypeScore rates how well the Go type typ matches query, ignoring case and
+// spaces: the same type, or the element type of a pointer, slice, array or
+// map (float64 matches []float64 and map[string]float64). Unlike names,
+// types are never matched approximately: float64 must not match float32.
+func TypeScore(query, typ string) int {
+	q, t := normalizeType(query), normalizeType(typ)
+	if q == "" {
+		return 0
+	}
+	if q == t {
+		return ScoreExact
+	}
+	for {
+		elem, ok := elementType(t)
+		if !ok {
+			return 0
+		}
+		if q == elem {
+			return ScoreElement
+		}
+		t = elem
+	}
+}
+
+// elementType strips one pointer, slice, array or map from the type t.
+func elementType(t string) (string, bool) {
+	switch {
+	case strings.HasPrefix(t, "*"):
+		return t[1:], true
+	case strings.HasPrefix(t, "map["), strings.HasPrefix(t, "["):
+		// Skip to the bracket closing the key or the length.
+		depth := 0
+		for i, r := range t {
+			switch r {
+			case '[':
+				depth++
+			case ']':
+				if depth--; depth == 0 {
+					return t[i+1:], true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func normalizeType(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// TextScore matches every word of query against the words of text and
+// returns the sum of the best scores, or 0 if a word has no match.
+func TextScore(query, text string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	words := split(text)
+	total := 0
+	for _, qw := range split(query) {
+		best := 0
+		for _, w := range words {
+			best = max(best, FuzzyScore(qw, w))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// EditDistance is the Levenshtein distance between a and b.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+}
@@ -0,0 +1,250 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		want             int
+	}{
+		{"account_id", "account_id", ScoreExact},
+		{"accountId", "account_id", ScoreExact},
+		{"AccountID", "accountID", ScoreExact},
+		{"account", "account_id", ScorePrefix},
+		{"account", "source_account", ScoreSubstring},
+		{"acount_id", "account_id", ScoreFuzzy - 10},
+		{"transfr", "Transfer", ScoreFuzzy - 10},
+		{"trnasfer", "Transfer", ScoreFuzzy - 20},
+		{"trnsfr", "Transfer", 0}, // 2 edits for a 6 character query
+		{"amont", "amount", ScoreFuzzy - 10},
+		{"amnt", "amount", 0}, // 2 edits for a 4 character query
+		{"abc", "abd", 0},     // No typos under 4 characters
+		{"", "amount", 0},
+		{"_", "amount", 0},
+		{"balance", "amount", 0},
+	}
+	for _, tt := range tests {
+		if got := FuzzyScore(tt.query, tt.candidate); got != tt.want {
+			t.Errorf("FuzzyScore(%q, %q) = %d, want %d", tt.query, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestTypeScore(t *testing.T) {
+	tests := []struct {
+		query, typ string
+		want       int
+	}{
+		{"float64", "float64", ScoreExact},
+		{"Account", "account", ScoreExact},
+		{"map[string]int", "map[string] int", ScoreExact},
+		{"float64", "[]float64", ScoreElement},
+		{"Account", "*Account", ScoreElement},
+		{"int", "map[string]int", ScoreElement},
+		{"int", "[4]int", ScoreElement},
+		{"Account", "map[string][]*Account", ScoreElement},
+		{"[]*Account", "map[string][]*Account", ScoreElement},
+		{"string", "map[string]int", 0}, // Keys are not elements
+		{"int", "map[[2]int]string", 0},
+		{"float64", "float32", 0},
+		{"uint64", "int64", 0},
+		{"int64", "uint64", 0},
+		{"int", "interface{}", 0},
+		{"int", "int64", 0},
+		{"Account", "Accounts", 0},
+		{"time", "time.Time", 0},
+		{"", "int", 0},
+	}
+	for _, tt := range tests {
+		if got := TypeScore(tt.query, tt.typ); got != tt.want {
+			t.Errorf("TypeScore(%q, %q) = %d, want %d", tt.query, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestTextScore(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        int
+	}{
+		{"transfers funds", "Transfer transfers funds between accounts.", 2 * ScoreExact},
+		{"funds transfer", "Transfer transfers funds between accounts.", 2 * ScoreExact},
+		{"fund", "Transfer transfers funds between accounts.", ScorePrefix},
+		{"funds refund", "Transfer transfers funds between accounts.", 0},
+		{"balance", "", 0},
+	}
+	for _, tt := range tests {
+		if got := TextScore(tt.query, tt.text); got != tt.want {
+			t.Errorf("TextScore(%q, %q) = %d, want %d", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"transfer", "transfr", 1},
+		{"año", "ano", 1},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+var services = []Service{
+	{
+		Namespace: "libreria-b", Method: "GetAccountStatus", Description: "GetAccountStatus returns the status of an account.",
+		Inputs:  []Param{{"account_id", "string"}},
+		Outputs: []Param{{"status", "string"}},
+	},
+	{
+		Namespace: "libreria-a", Receiver: "BankService", Method: "Transfer", Description: "Transfer moves funds between accounts.",
+		Inputs:  []Param{{"source_account", "string"}, {"amount", "float64"}},
+		Outputs: []Param{{"ok", "bool"}},
+	},
+	{
+		Namespace: "libreria-a", Method: "GetUserBalance", Description: "GetUserBalance returns the balance of an account.",
+		Inputs:  []Param{{"user_id", "string"}, {"account_id", "string"}},
+		Outputs: []Param{{"balance", "float64"}},
+	},
+}
+
+func TestRank(t *testing.T) {
+	type result struct {
+		Method string
+		Param  string
+		Side   string
+		Score  int
+	}
+	tests := []struct {
+		name  string
+		query Query
+		want  []result
+	}{
+		{
+			name:  "empty",
+			query: Query{},
+			want: []result{
+				{"libreria-a.BankService.Transfer", "", "", 0},
+				{"libreria-a.GetUserBalance", "", "", 0},
+				{"libreria-b.GetAccountStatus", "", "", 0},
+			},
+		},
+		{
+			name:  "param prefix before substring",
+			query: Query{Param: "account"},
+			want: []result{
+				{"libreria-a.GetUserBalance", "account_id", "Input", ScorePrefix},
+				{"libreria-b.GetAccountStatus", "account_id", "Input", ScorePrefix},
+				{"libreria-a.BankService.Transfer", "source_account", "Input", ScoreSubstring},
+			},
+		},
+		{
+			name:  "param typo",
+			query: Query{Param: "acount_id"},
+			want: []result{
+				{"libreria-a.GetUserBalance", "account_id", "Input", ScoreFuzzy - 10},
+				{"libreria-b.GetAccountStatus", "account_id", "Input", ScoreFuzzy - 10},
+			},
+		},
+		{
+			name:  "type outputs",
+			query: Query{Type: "float64", Outputs: true},
+			want: []result{
+				{"libreria-a.GetUserBalance", "balance", "Output", ScoreExact},
+			},
+		},
+		{
+			name:  "param and type on the same param",
+			query: Query{Param: "balance", Type: "string"},
+		},
+		{
+			name:  "param inputs only",
+			query: Query{Param: "status", Inputs: true},
+		},
+		{
+			name:  "method by receiver",
+			query: Query{Method: "BankService.Transfer"},
+			want: []result{
+				{"libreria-a.BankService.Transfer", "", "", ScoreExact},
+			},
+		},
+		{
+			name:  "method typo and text",
+			query: Query{Method: "transfr", Text: "funds"},
+			want: []result{
+				{"libreria-a.BankService.Transfer", "", "", ScoreFuzzy - 10 + ScoreExact},
+			},
+		},
+		{
+			name:  "text",
+			query: Query{Text: "balance account"},
+			want: []result{
+				{"libreria-a.GetUserBalance", "", "", 2 * ScoreExact},
+			},
+		},
+		{
+			name:  "text of another service",
+			query: Query{Text: "status account"},
+			want: []result{
+				{"libreria-b.GetAccountStatus", "", "", 2 * ScoreExact},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []result
+			for _, m := range Rank(services, tt.query) {
+				svc := services[m.Index]
+				got = append(got, result{svc.Namespace + "." + svc.FullMethod(), m.MatchedParam, m.ParamType, m.Score})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankTypeFilter(t *testing.T) {
+	typed := []Service{
+		{Namespace: "lib", Method: "Float32", Inputs: []Param{{"value", "float32"}}},
+		{Namespace: "lib", Method: "Float64", Inputs: []Param{{"value", "float64"}}},
+		{Namespace: "lib", Method: "Floats", Inputs: []Param{{"values", "[]float64"}}},
+		{Namespace: "lib", Method: "Int64", Inputs: []Param{{"value", "int64"}}},
+		{Namespace: "lib", Method: "Uint64", Inputs: []Param{{"value", "uint64"}}},
+		{Namespace: "lib", Method: "Any", Inputs: []Param{{"value", "interface{}"}}},
+		{Namespace: "lib", Method: "Counts", Inputs: []Param{{"counts", "map[string]int"}}},
+		{Namespace: "lib", Method: "Int", Inputs: []Param{{"value", "int"}}},
+	}
+	tests := []struct {
+		typ  string
+		want []string
+	}{
+		{"float64", []string{"Float64", "Floats"}},
+		{"float32", []string{"Float32"}},
+		{"uint64", []string{"Uint64"}},
+		{"int64", []string{"Int64"}},
+		{"int", []string{"Int", "Counts"}},
+		{"interface{}", []string{"Any"}},
+		{"float", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range Rank(typed, Query{Type: tt.typ}) {
+			got = append(got, typed[m.Index].Method)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("--type %s matched %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}